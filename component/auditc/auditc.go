@@ -0,0 +1,227 @@
+// Package auditc provides an append-only, hash-chained audit trail kept
+// apart from the application logs. Records bypass the logger entirely, so
+// they are never sampled, rate limited or filtered by level.
+package auditc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	sctx "github.com/phathdt/service-context"
+	"github.com/phathdt/service-context/core"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// AnonymousActor is recorded when the context carries no requester.
+const AnonymousActor = "anonymous"
+
+// Event describes who did what to which resource. Actor and TokenID are
+// filled from core.GetRequester when left empty.
+type Event struct {
+	Actor    string
+	TokenID  string
+	Action   string
+	Resource string
+	Outcome  string
+	Details  map[string]any
+}
+
+type AuditComponent interface {
+	GetAuditLogger() AuditLogger
+}
+
+type AuditLogger interface {
+	Log(ctx context.Context, e Event) error
+}
+
+type auditComp struct {
+	id     string
+	path   string
+	logger sctx.Logger
+
+	sync bool
+
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+	last Record
+}
+
+type Option func(*auditComp)
+
+// WithSync flushes the file to disk after every record, so a record Log
+// returned for survives a power loss. Without it records are handed to the
+// operating system on Log and only synced on Stop.
+func WithSync() Option {
+	return func(a *auditComp) {
+		a.sync = true
+	}
+}
+
+// New returns an audit component appending to the file at path. On
+// Activate the chain resumes from the last record already in the file; a
+// partial record left at its end by a crash is cut off.
+func New(id string, path string, opts ...Option) *auditComp {
+	a := &auditComp{id: id, path: path}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// NewWithWriter returns an audit component appending to w, starting a new
+// chain.
+func NewWithWriter(id string, w io.Writer) *auditComp {
+	return &auditComp{id: id, w: w}
+}
+
+func (a *auditComp) ID() string {
+	return a.id
+}
+
+func (a *auditComp) Activate(_ sctx.ServiceContext) error {
+	a.logger = sctx.GlobalLogger().GetLogger(a.id)
+
+	if a.path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(a.path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		a.logger.WithError(err).Error("Cannot open audit trail")
+		return err
+	}
+
+	last, end, err := lastRecord(f)
+	if err == nil {
+		err = a.dropPartialRecord(f, end)
+	}
+	if err != nil {
+		_ = f.Close()
+		a.logger.WithError(err).Error("Cannot resume audit trail")
+		return err
+	}
+
+	a.mu.Lock()
+	a.file, a.w, a.last = f, f, last
+	a.mu.Unlock()
+
+	a.logger.Infow("Audit trail opened", "path", a.path, "seq", last.Seq)
+
+	return nil
+}
+
+// dropPartialRecord truncates f to end, the end of its last complete
+// record, when a write cut short by a crash left a partial line after it.
+func (a *auditComp) dropPartialRecord(f *os.File, end int64) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == end {
+		return nil
+	}
+
+	a.logger.Warnw("Dropping partial audit record", "path", a.path, "bytes", info.Size()-end)
+
+	if err := f.Truncate(end); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+func (a *auditComp) Stop() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+
+	err := errors.Join(a.file.Sync(), a.file.Close())
+	a.file, a.w = nil, nil
+
+	return err
+}
+
+func (a *auditComp) GetAuditLogger() AuditLogger {
+	return a
+}
+
+// Log appends e to the trail. The record is written with a single Write so
+// a crash can't interleave it with another record.
+func (a *auditComp) Log(ctx context.Context, e Event) error {
+	rec := Record{
+		Time:     time.Now().UTC(),
+		Actor:    e.Actor,
+		TokenID:  e.TokenID,
+		Action:   e.Action,
+		Resource: e.Resource,
+		Outcome:  e.Outcome,
+	}
+
+	if rec.Actor == "" {
+		if requester := core.GetRequester(ctx); requester != nil {
+			rec.Actor = requester.GetSubject()
+			rec.TokenID = requester.GetTokenId()
+		} else {
+			rec.Actor = AnonymousActor
+		}
+	}
+
+	if len(e.Details) > 0 {
+		details, err := json.Marshal(e.Details)
+		if err != nil {
+			return err
+		}
+		rec.Details = details
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.w == nil {
+		return errors.New("audit trail is not open")
+	}
+
+	rec.Seq = a.last.Seq + 1
+	rec.PrevHash = a.last.Hash
+
+	line, err := rec.seal()
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.w.Write(line); err != nil {
+		if a.logger != nil {
+			a.logger.Errorw("Cannot write audit record", "seq", rec.Seq, sctx.ErrorAttr(err))
+		}
+		return err
+	}
+
+	if a.sync && a.file != nil {
+		if err := a.file.Sync(); err != nil {
+			if a.logger != nil {
+				a.logger.Errorw("Cannot sync audit record", "seq", rec.Seq, sctx.ErrorAttr(err))
+			}
+			return err
+		}
+	}
+
+	a.last = rec
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package auditc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumeDropsPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	a := New("audit", path, WithSync())
+	if err := a.Activate(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{"login", "logout"} {
+		if err := a.Log(context.Background(), Event{Actor: "alice", Action: action, Outcome: OutcomeSuccess}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of writing a third record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"seq":3,"time":"2026-`); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	a = New("audit", path)
+	if err := a.Activate(nil); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if a.last.Seq != 2 {
+		t.Fatalf("resumed at seq %d, want 2", a.last.Seq)
+	}
+	if err := a.Log(context.Background(), Event{Actor: "alice", Action: "login", Outcome: OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Verify(bytes.NewReader(b))
+	if err != nil || n != 3 {
+		t.Fatalf("Verify = %d, %v; want 3 valid records", n, err)
+	}
+}
@@ -0,0 +1,174 @@
+package auditc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxRecordSize bounds the length of one JSON line when reading a trail back.
+const maxRecordSize = 1 << 20
+
+// Record is one line of the audit trail. Hash is the SHA-256 of the record
+// encoded without Hash, and PrevHash links it to the record before it, so
+// editing, dropping or reordering records breaks the chain.
+type Record struct {
+	Seq      uint64          `json:"seq"`
+	Time     time.Time       `json:"time"`
+	Actor    string          `json:"actor"`
+	TokenID  string          `json:"token_id,omitempty"`
+	Action   string          `json:"action"`
+	Resource string          `json:"resource"`
+	Outcome  string          `json:"outcome"`
+	Details  json.RawMessage `json:"details,omitempty"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash,omitempty"`
+}
+
+// computeHash returns the hex encoded SHA-256 of r encoded without its hash.
+func (r Record) computeHash() (string, error) {
+	r.Hash = ""
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// seal sets r.Hash and returns the JSON line to append to the trail.
+func (r *Record) seal() ([]byte, error) {
+	hash, err := r.computeHash()
+	if err != nil {
+		return nil, err
+	}
+	r.Hash = hash
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// Verify reads an audit trail and checks that sequence numbers are
+// contiguous, every hash matches its record and every record links to the
+// previous one. It returns the number of valid records read and an error
+// describing the first broken record, if any.
+func Verify(r io.Reader) (uint64, error) {
+	var (
+		n    uint64
+		prev Record
+	)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return n, fmt.Errorf("audit record %d: %w", n+1, err)
+		}
+
+		if n > 0 && rec.Seq != prev.Seq+1 {
+			return n, fmt.Errorf("audit record %d: expected seq %d", rec.Seq, prev.Seq+1)
+		}
+
+		if rec.PrevHash != prev.Hash {
+			return n, fmt.Errorf("audit record %d: prev_hash does not match previous record", rec.Seq)
+		}
+
+		hash, err := rec.computeHash()
+		if err != nil {
+			return n, fmt.Errorf("audit record %d: %w", rec.Seq, err)
+		}
+
+		if hash != rec.Hash {
+			return n, fmt.Errorf("audit record %d: hash mismatch", rec.Seq)
+		}
+
+		prev = rec
+		n++
+	}
+
+	if err := sc.Err(); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// lastRecord returns the final record of a trail, or the zero Record when the
+// trail is empty, and the offset just past it. Bytes after the last newline
+// are a record whose write was interrupted and are ignored.
+func lastRecord(r io.Reader) (Record, int64, error) {
+	var (
+		last Record
+		line []byte
+		end  int64
+	)
+
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	for {
+		b, err := readLine(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return last, 0, err
+		}
+
+		end += int64(len(b))
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			line = append(line[:0], b...)
+		}
+	}
+
+	if line == nil {
+		return last, end, nil
+	}
+
+	if err := json.Unmarshal(line, &last); err != nil {
+		return last, 0, fmt.Errorf("cannot read last audit record: %w", err)
+	}
+
+	return last, end, nil
+}
+
+// readLine returns the next line of br including its newline, or io.EOF when
+// no complete line is left.
+func readLine(br *bufio.Reader) ([]byte, error) {
+	b, err := br.ReadSlice('\n')
+	if err == nil {
+		return b, nil
+	}
+
+	line := append([]byte(nil), b...)
+	for errors.Is(err, bufio.ErrBufferFull) {
+		if len(line) > maxRecordSize {
+			return nil, fmt.Errorf("audit record exceeds %d bytes", maxRecordSize)
+		}
+		b, err = br.ReadSlice('\n')
+		line = append(line, b...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
@@ -2,89 +2,121 @@ package pgxc
 
 import (
 	"context"
-	"regexp"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/tracelog"
 	sctx "github.com/phathdt/service-context"
 )
 
-const (
-	ansiReset        = "\033[0m"
-	ansiBrightBlue   = "\033[94m"
-	ansiBrightGreen  = "\033[92m"
-	ansiBrightYellow = "\033[93m"
-	ansiBrightRed    = "\033[91m"
-	ansiBrightCyan   = "\033[96m"
-)
-
 type PgxLogAdapter struct {
 	logger sctx.Logger
+	// level filters the logged events, the tracer itself runs at least at
+	// info when stats, metrics or the slow query log are on so they see
+	// every query.
+	level tracelog.LogLevel
+	// stats and metrics are nil unless enabled.
+	stats         *QueryStats
+	metrics       *QueryMetrics
+	slowThreshold time.Duration
+	redact        *redactor
 }
 
-// cleanSQL removes sqlc comments and minimizes SQL for logging
-func cleanSQL(sql string) string {
-	// Remove sqlc comments (-- name: ...)
-	lines := strings.Split(sql, "\n")
-	var cleanLines []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip empty lines and sqlc comments
-		if line == "" || strings.HasPrefix(line, "-- name:") {
-			continue
-		}
-		cleanLines = append(cleanLines, line)
+// colorizeSQL colors SQL by statement type using the logger's theme, which
+// is nil unless the output is colored text
+func colorizeSQL(sql string, sqlType string, theme *sctx.Theme) string {
+	if theme == nil {
+		return sql
 	}
 
-	// Join with spaces and clean up extra whitespace
-	result := strings.Join(cleanLines, " ")
+	return theme.SQLType(sqlType).Paint(sql)
+}
 
-	// Remove multiple spaces
-	re := regexp.MustCompile(`\s+`)
-	result = re.ReplaceAllString(result, " ")
+// pgxCallerSkip counts the pgx frames between the adapter and the code that
+// issued the query, so the logged source points at the application. depth
+// is the number of adapter functions on the stack, Log included.
+func pgxCallerSkip(depth int) int {
+	var pcs [32]uintptr
+	// Skip runtime.Callers, pgxCallerSkip and the adapter functions.
+	n := runtime.Callers(2+depth, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	skip := 0
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/jackc/pgx/") {
+			break
+		}
+		skip++
+		if !more {
+			break
+		}
+	}
 
-	return strings.TrimSpace(result)
+	return skip
 }
 
-// getSQLType determines the SQL operation type for coloring
-func getSQLType(sql string) string {
-	sqlLower := strings.ToLower(strings.TrimSpace(sql))
+// customLevel returns the level a record traced by pgx at level is logged
+// at.
+func customLevel(level tracelog.LogLevel) sctx.CustomLevel {
+	switch level {
+	case tracelog.LogLevelTrace, tracelog.LogLevelDebug:
+		return sctx.LevelDebug
+	case tracelog.LogLevelWarn:
+		return sctx.LevelWarn
+	case tracelog.LogLevelError:
+		return sctx.LevelError
+	default:
+		return sctx.LevelInfo
+	}
+}
 
-	if strings.HasPrefix(sqlLower, "select") {
-		return "select"
-	} else if strings.HasPrefix(sqlLower, "insert") {
-		return "insert"
-	} else if strings.HasPrefix(sqlLower, "update") {
-		return "update"
-	} else if strings.HasPrefix(sqlLower, "delete") {
-		return "delete"
-	} else if strings.HasPrefix(sqlLower, "create") || strings.HasPrefix(sqlLower, "alter") || strings.HasPrefix(sqlLower, "drop") {
-		return "ddl"
+// withCaller returns the logger for a record at level, reporting the caller
+// of pgx rather than the adapter. The pgx frames are only counted when the
+// record carries a source. depth is the number of adapter functions on the
+// stack when the returned logger is called, Log included.
+func (l *PgxLogAdapter) withCaller(ctx context.Context, level sctx.CustomLevel, depth int) sctx.Logger {
+	logger := l.logger.WithContext(ctx)
+	if s, ok := l.logger.(sctx.SourceRecorder); ok && !s.RecordsSource(level) {
+		return logger
 	}
 
-	return "other"
+	// withCaller itself is on the stack while the frames are counted
+	return logger.WithCallerSkip(depth + pgxCallerSkip(depth+1))
 }
 
-// colorizeSQL adds colors to SQL for text format only
-func colorizeSQL(sql string, sqlType string, isTextFormat bool) string {
-	if !isTextFormat {
-		return sql
+// observe records the duration of a finished query and logs it when slow.
+func (l *PgxLogAdapter) observe(ctx context.Context, msg string, stmt statement, data map[string]any) {
+	d, _ := data["time"].(time.Duration)
+
+	failed := data["err"] != nil
+
+	// COPY FROM has no statement to aggregate by
+	if msg == "CopyFrom" && l.metrics != nil {
+		l.metrics.Observe("copy", d, failed)
 	}
 
-	switch sqlType {
-	case "select":
-		return ansiBrightBlue + sql + ansiReset
-	case "insert":
-		return ansiBrightGreen + sql + ansiReset
-	case "update":
-		return ansiBrightYellow + sql + ansiReset
-	case "delete":
-		return ansiBrightRed + sql + ansiReset
-	case "ddl":
-		return ansiBrightCyan + sql + ansiReset
-	default:
-		return sql
+	if msg != "Query" {
+		return
+	}
+
+	if l.metrics != nil {
+		l.metrics.Observe(stmt.typ, d, failed)
+	}
+
+	if l.stats != nil {
+		l.stats.observe(stmt, d, failed)
+	}
+
+	if l.slowThreshold > 0 && d >= l.slowThreshold {
+		l.withCaller(ctx, sctx.LevelWarn, 2).Warnw("Slow query",
+			"query_name", stmt.name,
+			"sql", l.redact.sql(stmt.text),
+			"fingerprint", stmt.fingerprint,
+			"duration", d,
+			"threshold", l.slowThreshold,
+		)
 	}
 }
 
@@ -94,64 +126,81 @@ func (l *PgxLogAdapter) Log(ctx context.Context, level tracelog.LogLevel, msg st
 		return
 	}
 
-	// Detect if we're using text format for colorization
-	isTextFormat := l.logger.GetFormat() != "json"
+	logged := l.level == 0 || level <= l.level
+	observed := l.stats != nil || l.metrics != nil || l.slowThreshold > 0
+	if !logged && !observed {
+		return
+	}
 
 	// The actual SQL is in data["sql"], not in msg
+	sqlStr, hasSQL := data["sql"].(string)
+
+	var stmt statement
+	if hasSQL {
+		stmt = statementFor(ctx, sqlStr)
+	}
+
+	if observed {
+		l.observe(ctx, msg, stmt, data)
+	}
+
+	if !logged {
+		return
+	}
+
+	lvl := customLevel(level)
+	logger := l.withCaller(ctx, lvl, 1)
+
+	// Only colored text output has a theme
+	var theme *sctx.Theme
+	if t, ok := l.logger.(sctx.Themed); ok {
+		theme = t.GetTheme()
+	}
+
 	var actualSQL string
 	var sqlType string = "other"
 
-	if len(data) > 0 {
-		if sqlStr, ok := data["sql"].(string); ok {
-			actualSQL = cleanSQL(sqlStr)
-			sqlType = getSQLType(actualSQL)
-		}
+	if hasSQL {
+		actualSQL = l.redact.sql(stmt.text)
+		sqlType = stmt.typ
 	}
 
 	// Colorize the message if it's "Query" and we have SQL
 	displayMsg := msg
-	if msg == "Query" && actualSQL != "" && isTextFormat {
-		displayMsg = colorizeSQL(actualSQL, sqlType, isTextFormat)
+	if msg == "Query" && actualSQL != "" && theme != nil {
+		displayMsg = colorizeSQL(actualSQL, sqlType, theme)
 	}
 
 	// Use structured logging with Fields
-	logger := l.logger
 	if len(data) > 0 {
-		// Clean SQL in data if present
+		// Clean SQL and redact args in data if present
 		cleanedData := make(map[string]any)
 		for k, v := range data {
-			if k == "sql" {
-				if sqlStr, ok := v.(string); ok {
-					cleanedData[k] = cleanSQL(sqlStr)
+			switch k {
+			case "sql":
+				if hasSQL {
+					cleanedData[k] = actualSQL
+				} else {
+					cleanedData[k] = v
+				}
+			case "args":
+				if args, ok := v.([]any); ok {
+					cleanedData[k] = l.redact.args(stmt.text, args)
 				} else {
 					cleanedData[k] = v
 				}
-			} else {
+			default:
 				cleanedData[k] = v
 			}
 		}
 
 		// Add SQL type as metadata
 		cleanedData["sql_type"] = sqlType
-		logger = l.logger.Withs(sctx.Fields(cleanedData))
+		logger = logger.Withs(sctx.Fields(cleanedData))
 	} else {
 		// Even without data, add sql_type
-		logger = l.logger.Withs(sctx.Fields{"sql_type": sqlType})
+		logger = logger.Withs(sctx.Fields{"sql_type": sqlType})
 	}
 
-	// Just call the appropriate logger method
-	switch level {
-	case tracelog.LogLevelTrace:
-		logger.Debug(displayMsg)
-	case tracelog.LogLevelDebug:
-		logger.Debug(displayMsg)
-	case tracelog.LogLevelInfo:
-		logger.Info(displayMsg)
-	case tracelog.LogLevelWarn:
-		logger.Warn(displayMsg)
-	case tracelog.LogLevelError:
-		logger.Error(displayMsg)
-	default:
-		logger.Info(displayMsg)
-	}
+	logger.LogAttrs(lvl, displayMsg)
 }
@@ -0,0 +1,62 @@
+package pgxc
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/tracelog"
+	sctx "github.com/phathdt/service-context"
+)
+
+// traceQuery runs a successful query through a tracer logging to logger.
+func traceQuery(ctx context.Context, logger sctx.Logger, sql string, args ...any) {
+	tl := &tracelog.TraceLog{
+		Logger:   &PgxLogAdapter{logger: logger, level: tracelog.LogLevelDebug},
+		LogLevel: tracelog.LogLevelDebug,
+	}
+
+	// A zero Conn stands in for a connection, tracelog only reads its PID.
+	conn := &pgx.Conn{}
+	ctx = tl.TraceQueryStart(ctx, conn, pgx.TraceQueryStartData{SQL: sql, Args: args})
+	tl.TraceQueryEnd(ctx, conn, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+}
+
+func TestAdapterLogsWithQueryContext(t *testing.T) {
+	var buf bytes.Buffer
+	app := sctx.NewAppLogger(&sctx.Config{
+		Handler: slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	})
+
+	ctx, capture := sctx.NewCaptureContext(context.Background(), sctx.CaptureConfig{})
+	traceQuery(ctx, app.GetLogger("db"), "select * from users where id = $1", 1)
+
+	if buf.Len() != 0 {
+		t.Fatalf("query below the logger level was written before the capture ended: %s", buf.String())
+	}
+
+	capture.Flush()
+
+	if !strings.Contains(buf.String(), `"sql":"select * from users where id = $1"`) {
+		t.Errorf("flushed output = %s, want the captured query", buf.String())
+	}
+}
+
+func TestAdapterReportsQueryCaller(t *testing.T) {
+	var buf bytes.Buffer
+	app := sctx.NewAppLogger(&sctx.Config{
+		Handler:   slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}),
+		AddSource: true,
+	})
+
+	traceQuery(context.Background(), app.GetLogger("db"), "select 1")
+
+	// The caller is traceQuery, which issues the query through tracelog.
+	if !strings.Contains(buf.String(), "adapter_test.go") {
+		t.Errorf("output = %s, want the caller of pgx as source", buf.String())
+	}
+}
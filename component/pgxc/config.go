@@ -0,0 +1,367 @@
+package pgxc
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/tracelog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config holds the pool settings of a pgx component. Zero values keep the
+// value from the DSN, or the pgx default.
+type Config struct {
+	MaxConns          int32         `yaml:"max_conns"           mapstructure:"max_conns"`
+	MinConns          int32         `yaml:"min_conns"           mapstructure:"min_conns"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime"   mapstructure:"max_conn_lifetime"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time"  mapstructure:"max_conn_idle_time"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" mapstructure:"health_check_period"`
+
+	// Server side timeouts, sent as runtime parameters on connect.
+	StatementTimeout                time.Duration `yaml:"statement_timeout"                   mapstructure:"statement_timeout"`
+	LockTimeout                     time.Duration `yaml:"lock_timeout"                        mapstructure:"lock_timeout"`
+	IdleInTransactionSessionTimeout time.Duration `yaml:"idle_in_transaction_session_timeout" mapstructure:"idle_in_transaction_session_timeout"`
+
+	ApplicationName string `yaml:"application_name" mapstructure:"application_name"`
+	SearchPath      string `yaml:"search_path"      mapstructure:"search_path"`
+
+	// LogLevel is the level of the query tracer: "trace", "debug", "info",
+	// "warn", "error" or "none". Defaults to "debug".
+	LogLevel string `yaml:"log_level" mapstructure:"log_level"`
+
+	// SlowQueryThreshold logs queries taking at least this long at warn
+	// level, whatever LogLevel is. Zero disables the slow query log.
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" mapstructure:"slow_query_threshold"`
+
+	// QueryStats aggregates the durations of the queries by statement, see
+	// GetQueryStats.
+	QueryStats bool `yaml:"query_stats" mapstructure:"query_stats"`
+	// QueryMetrics keeps duration histograms of the queries by statement
+	// type, see GetQueryMetrics. The query metrics of package pgxprom
+	// require it.
+	QueryMetrics bool `yaml:"query_metrics" mapstructure:"query_metrics"`
+
+	// Query arguments are logged unless redacted. RedactArgs hides all of
+	// them, RedactArgPositions the given placeholders ($1 is 1) and
+	// RedactColumns those compared with or inserted into a column whose name
+	// contains one of the given words, e.g. "password" or "email". Columns
+	// are recognized bare or wrapped in single argument functions such as
+	// lower(email), not inside other expressions.
+	RedactArgs         bool     `yaml:"redact_args"          mapstructure:"redact_args"`
+	RedactArgPositions []int    `yaml:"redact_arg_positions" mapstructure:"redact_arg_positions"`
+	RedactColumns      []string `yaml:"redact_columns"       mapstructure:"redact_columns"`
+
+	// MaxSQLLength and MaxArgLength truncate the logged SQL and arguments to
+	// that many bytes. Zero logs the whole SQL, and string arguments cut at
+	// 64 bytes by pgx.
+	MaxSQLLength int `yaml:"max_sql_length" mapstructure:"max_sql_length"`
+	MaxArgLength int `yaml:"max_arg_length" mapstructure:"max_arg_length"`
+
+	// Tracing creates an OpenTelemetry span per query, batch, copy and
+	// connect, using the global tracer provider unless one is given with
+	// WithTracerProvider.
+	Tracing bool `yaml:"tracing" mapstructure:"tracing"`
+
+	// StatementCacheMode is the default query exec mode: "cache_statement",
+	// "cache_describe", "describe_exec", "exec" or "simple_protocol". Use
+	// "exec" or "simple_protocol" behind PgBouncer in transaction mode.
+	StatementCacheMode     string `yaml:"statement_cache_mode"     mapstructure:"statement_cache_mode"`
+	StatementCacheCapacity int    `yaml:"statement_cache_capacity" mapstructure:"statement_cache_capacity"`
+
+	// TxMaxAttempts bounds how often TxManager.WithTx runs a transaction
+	// failing with a serialization failure or deadlock. Defaults to 3, 1
+	// disables retries. The wait between attempts starts at TxRetryBackoff
+	// (10ms) and doubles up to TxRetryMaxBackoff (1s).
+	TxMaxAttempts     int           `yaml:"tx_max_attempts"      mapstructure:"tx_max_attempts"`
+	TxRetryBackoff    time.Duration `yaml:"tx_retry_backoff"     mapstructure:"tx_retry_backoff"`
+	TxRetryMaxBackoff time.Duration `yaml:"tx_retry_max_backoff" mapstructure:"tx_retry_max_backoff"`
+
+	// MigrateOnActivate applies the migrations given with WithMigrations
+	// when the component is activated.
+	MigrateOnActivate bool   `yaml:"migrate_on_activate" mapstructure:"migrate_on_activate"`
+	MigrationsTable   string `yaml:"migrations_table"    mapstructure:"migrations_table"`
+	// MigrateDryRun makes the migrator, on activation and from GetMigrator,
+	// log the migrations it would run without changing the database.
+	MigrateDryRun bool `yaml:"migrate_dry_run" mapstructure:"migrate_dry_run"`
+
+	// ReplicaSelection picks the replica used by ReplicatedComp.Replica:
+	// "round_robin" (default) or "least_conns". Replicas failing the ping
+	// run every ReplicaCheckPeriod (5s) are skipped until they recover.
+	ReplicaSelection   string        `yaml:"replica_selection"    mapstructure:"replica_selection"`
+	ReplicaCheckPeriod time.Duration `yaml:"replica_check_period" mapstructure:"replica_check_period"`
+}
+
+type Option func(*pgxComp)
+
+// WithConfig replaces all settings, e.g. with a Config loaded from a file.
+func WithConfig(cfg Config) Option {
+	return func(p *pgxComp) {
+		p.cfg = cfg
+	}
+}
+
+func WithMaxConns(n int32) Option {
+	return func(p *pgxComp) {
+		p.cfg.MaxConns = n
+	}
+}
+
+func WithMinConns(n int32) Option {
+	return func(p *pgxComp) {
+		p.cfg.MinConns = n
+	}
+}
+
+func WithMaxConnLifetime(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.MaxConnLifetime = d
+	}
+}
+
+func WithMaxConnIdleTime(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.MaxConnIdleTime = d
+	}
+}
+
+func WithHealthCheckPeriod(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.HealthCheckPeriod = d
+	}
+}
+
+func WithStatementTimeout(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.StatementTimeout = d
+	}
+}
+
+func WithLockTimeout(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.LockTimeout = d
+	}
+}
+
+func WithIdleInTransactionSessionTimeout(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.IdleInTransactionSessionTimeout = d
+	}
+}
+
+func WithApplicationName(name string) Option {
+	return func(p *pgxComp) {
+		p.cfg.ApplicationName = name
+	}
+}
+
+func WithSearchPath(path string) Option {
+	return func(p *pgxComp) {
+		p.cfg.SearchPath = path
+	}
+}
+
+func WithLogLevel(level string) Option {
+	return func(p *pgxComp) {
+		p.cfg.LogLevel = level
+	}
+}
+
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.SlowQueryThreshold = d
+	}
+}
+
+func WithQueryStats() Option {
+	return func(p *pgxComp) {
+		p.cfg.QueryStats = true
+	}
+}
+
+func WithQueryMetrics() Option {
+	return func(p *pgxComp) {
+		p.cfg.QueryMetrics = true
+	}
+}
+
+func WithRedactArgs() Option {
+	return func(p *pgxComp) {
+		p.cfg.RedactArgs = true
+	}
+}
+
+func WithRedactArgPositions(positions ...int) Option {
+	return func(p *pgxComp) {
+		p.cfg.RedactArgPositions = positions
+	}
+}
+
+func WithRedactColumns(columns ...string) Option {
+	return func(p *pgxComp) {
+		p.cfg.RedactColumns = columns
+	}
+}
+
+func WithMaxSQLLength(n int) Option {
+	return func(p *pgxComp) {
+		p.cfg.MaxSQLLength = n
+	}
+}
+
+func WithMaxArgLength(n int) Option {
+	return func(p *pgxComp) {
+		p.cfg.MaxArgLength = n
+	}
+}
+
+func WithTracing() Option {
+	return func(p *pgxComp) {
+		p.cfg.Tracing = true
+	}
+}
+
+// WithTracerProvider enables tracing with tp rather than the global tracer
+// provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *pgxComp) {
+		p.tracerProvider = tp
+		p.cfg.Tracing = true
+	}
+}
+
+func WithStatementCacheMode(mode string) Option {
+	return func(p *pgxComp) {
+		p.cfg.StatementCacheMode = mode
+	}
+}
+
+func WithStatementCacheCapacity(n int) Option {
+	return func(p *pgxComp) {
+		p.cfg.StatementCacheCapacity = n
+	}
+}
+
+func WithTxRetries(maxAttempts int, backoff, maxBackoff time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.TxMaxAttempts = maxAttempts
+		p.cfg.TxRetryBackoff = backoff
+		p.cfg.TxRetryMaxBackoff = maxBackoff
+	}
+}
+
+// WithMigrations sets the source of the migrations run by GetMigrator, see
+// Migration for the file layout.
+func WithMigrations(fsys fs.FS) Option {
+	return func(p *pgxComp) {
+		p.migrations = fsys
+	}
+}
+
+func WithMigrateOnActivate() Option {
+	return func(p *pgxComp) {
+		p.cfg.MigrateOnActivate = true
+	}
+}
+
+func WithMigrateDryRun() Option {
+	return func(p *pgxComp) {
+		p.cfg.MigrateDryRun = true
+	}
+}
+
+func WithReplicaSelection(selection string) Option {
+	return func(p *pgxComp) {
+		p.cfg.ReplicaSelection = selection
+	}
+}
+
+func WithReplicaCheckPeriod(d time.Duration) Option {
+	return func(p *pgxComp) {
+		p.cfg.ReplicaCheckPeriod = d
+	}
+}
+
+// apply copies the non-zero settings onto a pool config parsed from the DSN.
+func (c Config) apply(config *pgxpool.Config) error {
+	if c.MaxConns > 0 {
+		config.MaxConns = c.MaxConns
+	}
+	if c.MinConns > 0 {
+		config.MinConns = c.MinConns
+	}
+	if c.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = c.MaxConnLifetime
+	}
+	if c.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = c.MaxConnIdleTime
+	}
+	if c.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = c.HealthCheckPeriod
+	}
+
+	params := config.ConnConfig.RuntimeParams
+	setTimeout := func(name string, d time.Duration) {
+		if d > 0 {
+			params[name] = strconv.FormatInt(d.Milliseconds(), 10)
+		}
+	}
+	setTimeout("statement_timeout", c.StatementTimeout)
+	setTimeout("lock_timeout", c.LockTimeout)
+	setTimeout("idle_in_transaction_session_timeout", c.IdleInTransactionSessionTimeout)
+
+	if c.ApplicationName != "" {
+		params["application_name"] = c.ApplicationName
+	}
+	if c.SearchPath != "" {
+		params["search_path"] = c.SearchPath
+	}
+
+	if c.StatementCacheMode != "" {
+		mode, err := parseQueryExecMode(c.StatementCacheMode)
+		if err != nil {
+			return err
+		}
+		config.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if c.StatementCacheCapacity > 0 {
+		config.ConnConfig.StatementCacheCapacity = c.StatementCacheCapacity
+	}
+
+	return nil
+}
+
+func (c Config) logLevel() (tracelog.LogLevel, error) {
+	if c.LogLevel == "" {
+		return tracelog.LogLevelDebug, nil
+	}
+
+	level, err := tracelog.LogLevelFromString(c.LogLevel)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pgx log level %q", c.LogLevel)
+	}
+
+	return level, nil
+}
+
+// parseQueryExecMode accepts the names used by the default_query_exec_mode
+// DSN parameter.
+func parseQueryExecMode(s string) (pgx.QueryExecMode, error) {
+	switch s {
+	case "cache_statement":
+		return pgx.QueryExecModeCacheStatement, nil
+	case "cache_describe":
+		return pgx.QueryExecModeCacheDescribe, nil
+	case "describe_exec":
+		return pgx.QueryExecModeDescribeExec, nil
+	case "exec":
+		return pgx.QueryExecModeExec, nil
+	case "simple_protocol":
+		return pgx.QueryExecModeSimpleProtocol, nil
+	default:
+		return 0, fmt.Errorf("invalid statement cache mode %q", s)
+	}
+}
@@ -0,0 +1,103 @@
+package pgxc
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// QueryDurationBuckets are the upper bounds of the histogram buckets of
+// OperationStats.
+var QueryDurationBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// OperationStats is a histogram of the durations of one type of statement,
+// such as "select" or "copy".
+type OperationStats struct {
+	Count  uint64
+	Errors uint64
+	Sum    time.Duration
+	// Buckets holds the cumulative count of executions taking at most the
+	// duration at the same index of QueryDurationBuckets.
+	Buckets []uint64
+}
+
+type operationStats struct {
+	count   uint64
+	errors  uint64
+	sum     time.Duration
+	buckets []uint64
+}
+
+// QueryMetrics collects duration histograms of the queries run through a
+// pgxc component by statement type. Unlike QueryStats it doesn't track
+// statements, so its size is bounded by the number of statement types. The
+// zero value is ready to use.
+type QueryMetrics struct {
+	mu  sync.Mutex
+	ops map[string]*operationStats
+}
+
+// Observe adds an execution of a statement of type op to its histogram.
+func (m *QueryMetrics) Observe(op string, d time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ops == nil {
+		m.ops = make(map[string]*operationStats)
+	}
+
+	st, ok := m.ops[op]
+	if !ok {
+		st = &operationStats{buckets: make([]uint64, len(QueryDurationBuckets))}
+		m.ops[op] = st
+	}
+
+	st.count++
+	if failed {
+		st.errors++
+	}
+	st.sum += d
+
+	if i, _ := slices.BinarySearch(QueryDurationBuckets, d); i < len(st.buckets) {
+		st.buckets[i]++
+	}
+}
+
+// Operations returns the duration histograms by statement type. They are
+// never reset, so they can be exported as monotonic counters.
+func (m *QueryMetrics) Operations() map[string]OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]OperationStats, len(m.ops))
+	for op, st := range m.ops {
+		buckets := make([]uint64, len(st.buckets))
+		var n uint64
+		for i, c := range st.buckets {
+			n += c
+			buckets[i] = n
+		}
+
+		out[op] = OperationStats{
+			Count:   st.count,
+			Errors:  st.errors,
+			Sum:     st.sum,
+			Buckets: buckets,
+		}
+	}
+
+	return out
+}
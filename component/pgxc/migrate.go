@@ -0,0 +1,363 @@
+package pgxc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	sctx "github.com/phathdt/service-context"
+)
+
+const defaultMigrationsTable = "schema_migrations"
+
+// Migration is one versioned schema change read from a migration source.
+//
+// Files are named "<version>_<name>.up.sql" and "<version>_<name>.down.sql",
+// as used by golang-migrate and understood by sqlc. A plain
+// "<version>_<name>.sql" file is an up migration without a down.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Missing is set for applied versions not found in the source.
+	Missing bool
+}
+
+type Migrator interface {
+	// Up applies all pending migrations in version order, each in its own
+	// transaction, and returns the ones applied. In dry-run mode it returns
+	// the pending migrations without applying them.
+	Up(ctx context.Context) ([]Migration, error)
+	// Down reverts the latest applied migration, if any.
+	Down(ctx context.Context) (*Migration, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+type MigrateConfig struct {
+	// Table records the applied versions. Defaults to "schema_migrations".
+	Table string
+	// DryRun makes Up and Down log what they would do without changing the
+	// database.
+	DryRun bool
+}
+
+type migrator struct {
+	pool   *pgxpool.Pool
+	fsys   fs.FS
+	cfg    MigrateConfig
+	table  string
+	lockID int64
+	logger sctx.Logger
+}
+
+// NewMigrator returns a migrator applying the migrations found at the root
+// of fsys, e.g. an embed.FS narrowed with fs.Sub.
+func NewMigrator(pool *pgxpool.Pool, fsys fs.FS, cfg MigrateConfig, logger sctx.Logger) Migrator {
+	if cfg.Table == "" {
+		cfg.Table = defaultMigrationsTable
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("pgxc.migrate." + cfg.Table))
+
+	return &migrator{
+		pool:   pool,
+		fsys:   fsys,
+		cfg:    cfg,
+		table:  pgx.Identifier(strings.Split(cfg.Table, ".")).Sanitize(),
+		lockID: int64(h.Sum64()),
+		logger: logger,
+	}
+}
+
+// ReadMigrations parses the migration files at the root of fsys.
+func ReadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		down := strings.HasSuffix(base, ".down")
+		base = strings.TrimSuffix(strings.TrimSuffix(base, ".up"), ".down")
+
+		v, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: version must be a number", e.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, name)
+		}
+
+		target := &m.Up
+		if down {
+			target = &m.Down
+		}
+		if *target != "" {
+			return nil, fmt.Errorf("migration %d: duplicate file %s", version, e.Name())
+		}
+		*target = string(body)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d: missing up file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrations, err := ReadMigrations(m.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+
+			if m.cfg.DryRun {
+				m.logger.Infow("Would apply migration", "version", mig.Version, "name", mig.Name)
+				applied = append(applied, mig)
+				continue
+			}
+
+			m.logger.Infow("Applying migration", "version", mig.Version, "name", mig.Name)
+			if err := m.exec(ctx, conn, mig.Up, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, "INSERT INTO "+m.table+" (version, name) VALUES ($1, $2)", mig.Version, mig.Name)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+func (m *migrator) Down(ctx context.Context) (*Migration, error) {
+	migrations, err := ReadMigrations(m.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted *Migration
+	err = m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		var latest int64 = -1
+		for v := range done {
+			if v > latest {
+				latest = v
+			}
+		}
+		if latest < 0 {
+			return nil
+		}
+
+		idx := sort.Search(len(migrations), func(i int) bool { return migrations[i].Version >= latest })
+		if idx == len(migrations) || migrations[idx].Version != latest {
+			return fmt.Errorf("migration %d: applied but not found in source", latest)
+		}
+
+		mig := migrations[idx]
+		if mig.Down == "" {
+			return fmt.Errorf("migration %d_%s: no down migration", mig.Version, mig.Name)
+		}
+
+		if m.cfg.DryRun {
+			m.logger.Infow("Would revert migration", "version", mig.Version, "name", mig.Name)
+			reverted = &mig
+			return nil
+		}
+
+		m.logger.Infow("Reverting migration", "version", mig.Version, "name", mig.Name)
+		if err := m.exec(ctx, conn, mig.Down, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM "+m.table+" WHERE version = $1", mig.Version)
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		reverted = &mig
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := ReadMigrations(m.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	done, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, mig := range migrations {
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if a, ok := done[mig.Version]; ok {
+			s.Applied, s.AppliedAt = true, a.appliedAt
+			delete(done, mig.Version)
+		}
+		status = append(status, s)
+	}
+
+	for v, a := range done {
+		status = append(status, MigrationStatus{Version: v, Name: a.name, Applied: true, AppliedAt: a.appliedAt, Missing: true})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+
+	return status, nil
+}
+
+// locked runs fn on a connection holding the migration advisory lock, so
+// replicas starting together apply migrations one at a time.
+func (m *migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) (err error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return err
+	}
+	defer func() {
+		if _, unlockErr := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", m.lockID); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	if !m.cfg.DryRun {
+		if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+m.table+` (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+			return err
+		}
+	}
+
+	return fn(conn)
+}
+
+// applied returns the recorded versions, or none when the table doesn't
+// exist yet.
+func (m *migrator) applied(ctx context.Context, conn *pgxpool.Conn) (map[int64]appliedMigration, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", m.table).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int64]appliedMigration)
+	if !exists {
+		return done, nil
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version, name, applied_at FROM "+m.table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			v int64
+			a appliedMigration
+		)
+		if err := rows.Scan(&v, &a.name, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		done[v] = a
+	}
+
+	return done, rows.Err()
+}
+
+// exec runs sql and record in one transaction.
+func (m *migrator) exec(ctx context.Context, conn *pgxpool.Conn, sql string, record func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,407 @@
+package pgxc
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota // keyword or identifier
+	tokIdent                   // "quoted identifier"
+	tokString                  // '...', E'...' or $tag$...$tag$
+	tokNumber                  // numeric literal
+	tokParam                   // $1
+	tokPunct                   // operator or punctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// space is set when whitespace or a comment preceded the token.
+	space bool
+}
+
+// operatorChars are the characters PostgreSQL operators are made of.
+const operatorChars = "+-*/<>=~!@#%^&|`?:"
+
+// statementTypes classifies statements by their leading keyword.
+var statementTypes = map[string]string{
+	"select":   "select",
+	"values":   "select",
+	"table":    "select",
+	"insert":   "insert",
+	"update":   "update",
+	"delete":   "delete",
+	"merge":    "merge",
+	"copy":     "copy",
+	"create":   "ddl",
+	"alter":    "ddl",
+	"drop":     "ddl",
+	"truncate": "ddl",
+}
+
+// statement is a query as logged and aggregated by the adapter.
+type statement struct {
+	// name is the sqlc "-- name:" annotation, if any.
+	name string
+	// text is the SQL without comments and with whitespace collapsed.
+	text string
+	// generic is text with literals replaced by "?" and lists of literals
+	// or placeholders by "(...)".
+	generic string
+	typ     string
+	// operation is the keyword typ was derived from, e.g. "SELECT" or
+	// "VALUES".
+	operation   string
+	fingerprint string
+}
+
+// Fingerprint returns a hash of sql that ignores comments, whitespace,
+// keyword case, literal values and the length of lists of literals or
+// placeholders, so variants of the same query share a fingerprint.
+func Fingerprint(sql string) string {
+	return parseStatement(sql).fingerprint
+}
+
+type statementKey struct{}
+
+// parsedStatement is a statement stored in the context of a query, along
+// with the SQL it was parsed from.
+type parsedStatement struct {
+	sql  string
+	stmt statement
+}
+
+// withStatement stores stmt, parsed from sql, in the context of a query so
+// the tracers of the same query don't parse it again.
+func withStatement(ctx context.Context, sql string, stmt statement) context.Context {
+	return context.WithValue(ctx, statementKey{}, &parsedStatement{sql: sql, stmt: stmt})
+}
+
+// statementFor returns the statement stored in ctx when it was parsed from
+// sql, and parses sql otherwise, e.g. for the queries of a batch.
+func statementFor(ctx context.Context, sql string) statement {
+	if p, ok := ctx.Value(statementKey{}).(*parsedStatement); ok && p.sql == sql {
+		return p.stmt
+	}
+
+	return parseStatement(sql)
+}
+
+func parseStatement(sql string) statement {
+	tokens, name := tokenize(sql)
+
+	st := statement{name: name}
+	st.typ, st.operation = statementType(tokens)
+
+	var text, generic strings.Builder
+	h := fnv.New64a()
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+
+		if t.space {
+			text.WriteByte(' ')
+			generic.WriteByte(' ')
+		}
+		text.WriteString(t.text)
+
+		part := t.text
+		switch t.kind {
+		case tokString, tokNumber:
+			part = "?"
+		case tokPunct:
+			if end, ok := literalList(tokens, i); ok {
+				part = "(...)"
+				for _, skipped := range tokens[i+1 : end+1] {
+					if skipped.space {
+						text.WriteByte(' ')
+					}
+					text.WriteString(skipped.text)
+				}
+				i = end
+			}
+		}
+		generic.WriteString(part)
+
+		// Keywords and unquoted identifiers are case insensitive, and a
+		// trailing semicolon doesn't change the query.
+		if t.kind == tokWord {
+			part = strings.ToLower(part)
+		}
+		if part == ";" && i == len(tokens)-1 {
+			continue
+		}
+		_, _ = h.Write([]byte(part))
+		_, _ = h.Write([]byte{0})
+	}
+
+	st.text = text.String()
+	st.generic = generic.String()
+	st.fingerprint = fmt.Sprintf("%016x", h.Sum64())
+
+	return st
+}
+
+// literalList reports whether tokens[i] opens a parenthesized list of
+// literals or placeholders, such as "IN ($1, $2)", and returns the index of
+// its closing parenthesis.
+func literalList(tokens []token, i int) (int, bool) {
+	if tokens[i].text != "(" {
+		return 0, false
+	}
+
+	value := true
+	for j := i + 1; j < len(tokens); j++ {
+		t := tokens[j]
+		switch {
+		case value && (t.kind == tokString || t.kind == tokNumber || t.kind == tokParam):
+			value = false
+		case !value && t.text == ",":
+			value = true
+		case !value && t.text == ")":
+			return j, true
+		default:
+			return 0, false
+		}
+	}
+
+	return 0, false
+}
+
+// statementType returns "select", "insert", "update", "delete", "merge",
+// "copy", "ddl" or "other", and the keyword it was derived from. The type of
+// a WITH query is that of the statement following its common table
+// expressions.
+func statementType(tokens []token) (string, string) {
+	first := -1
+	for i, t := range tokens {
+		if t.kind == tokWord {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "other", ""
+	}
+
+	kw := strings.ToUpper(tokens[first].text)
+	if kw != "WITH" {
+		if typ, ok := statementTypes[strings.ToLower(kw)]; ok {
+			return typ, kw
+		}
+		return "other", kw
+	}
+
+	depth := 0
+	for _, t := range tokens[first+1:] {
+		switch {
+		case t.text == "(":
+			depth++
+		case t.text == ")":
+			depth--
+		case depth == 0 && t.kind == tokWord:
+			if typ, ok := statementTypes[strings.ToLower(t.text)]; ok {
+				return typ, strings.ToUpper(t.text)
+			}
+		}
+	}
+
+	return "other", kw
+}
+
+// tokenize splits sql into tokens, dropping comments and whitespace, and
+// returns the sqlc query name found in a "-- name:" comment.
+func tokenize(sql string) ([]token, string) {
+	var (
+		tokens []token
+		name   string
+		space  bool
+	)
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		kind := tokPunct
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+			space = true
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			if name == "" {
+				name = sqlcName(sql[i+2 : i+end])
+			}
+			i += end
+			space = true
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipBlockComment(sql, i)
+			space = true
+			continue
+		case c == '\'':
+			kind, i = tokString, scanQuoted(sql, i, '\'', false)
+		case c == '"':
+			kind, i = tokIdent, scanQuoted(sql, i, '"', false)
+		case c == '$':
+			kind, i = scanDollar(sql, i)
+		case isDigit(c) || c == '.' && i+1 < len(sql) && isDigit(sql[i+1]):
+			kind, i = tokNumber, scanNumber(sql, i)
+		case isWordStart(c):
+			kind, i = tokWord, scanWord(sql, i)
+			// E'...' escape strings, and B'...', X'...' and N'...'
+			if i-start == 1 && i < len(sql) && sql[i] == '\'' && strings.IndexByte("eEbBxXnN", c) >= 0 {
+				kind, i = tokString, scanQuoted(sql, i, '\'', c == 'e' || c == 'E')
+			}
+		case strings.IndexByte(operatorChars, c) >= 0:
+			i = scanOperator(sql, i)
+		default:
+			i++
+		}
+
+		tokens = append(tokens, token{kind: kind, text: sql[start:i], space: space && len(tokens) > 0})
+		space = false
+	}
+
+	return tokens, name
+}
+
+// sqlcName returns the query name of a "-- name: GetUser :one" comment.
+func sqlcName(comment string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(comment), "name:")
+	if !ok {
+		return ""
+	}
+
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return ""
+}
+
+// skipBlockComment returns the end of the, possibly nested, block comment
+// starting at i.
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+
+	return len(sql)
+}
+
+// scanQuoted returns the end of the string or identifier quoted with q at
+// i, where a doubled quote is part of the value.
+func scanQuoted(sql string, i int, q byte, backslash bool) int {
+	for j := i + 1; j < len(sql); j++ {
+		switch {
+		case backslash && sql[j] == '\\':
+			j++
+		case sql[j] == q:
+			if j+1 < len(sql) && sql[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+
+	return len(sql)
+}
+
+// scanDollar scans a placeholder or a dollar-quoted string at i.
+func scanDollar(sql string, i int) (tokenKind, int) {
+	j := i + 1
+	if j < len(sql) && isDigit(sql[j]) {
+		for j < len(sql) && isDigit(sql[j]) {
+			j++
+		}
+		return tokParam, j
+	}
+
+	for j < len(sql) && (isWordStart(sql[j]) || isDigit(sql[j])) {
+		j++
+	}
+	if j >= len(sql) || sql[j] != '$' {
+		return tokPunct, i + 1
+	}
+
+	tag := sql[i : j+1]
+	end := strings.Index(sql[j+1:], tag)
+	if end < 0 {
+		return tokString, len(sql)
+	}
+
+	return tokString, j + 1 + end + len(tag)
+}
+
+func scanNumber(sql string, i int) int {
+	for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+		i++
+	}
+
+	if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+		j := i + 1
+		if j < len(sql) && (sql[j] == '+' || sql[j] == '-') {
+			j++
+		}
+		if j < len(sql) && isDigit(sql[j]) {
+			i = j
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+		}
+	}
+
+	return i
+}
+
+func scanWord(sql string, i int) int {
+	for i < len(sql) && (isWordStart(sql[i]) || isDigit(sql[i]) || sql[i] == '$') {
+		i++
+	}
+
+	return i
+}
+
+// scanOperator returns the end of the operator at i, which stops before a
+// comment.
+func scanOperator(sql string, i int) int {
+	j := i + 1
+	for j < len(sql) && strings.IndexByte(operatorChars, sql[j]) >= 0 &&
+		!strings.HasPrefix(sql[j:], "--") && !strings.HasPrefix(sql[j:], "/*") {
+		j++
+	}
+
+	return j
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
@@ -0,0 +1,174 @@
+package pgxc
+
+import "testing"
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want statement
+	}{
+		{
+			name: "select",
+			sql:  "SELECT id FROM users WHERE email = 'bob@example.com' AND age > 30",
+			want: statement{
+				typ: "select", operation: "SELECT",
+				text:    "SELECT id FROM users WHERE email = 'bob@example.com' AND age > 30",
+				generic: "SELECT id FROM users WHERE email = ? AND age > ?",
+			},
+		},
+		{
+			name: "sqlc name",
+			sql:  "-- name: GetUser :one\nSELECT * FROM users WHERE id = $1",
+			want: statement{
+				name: "GetUser", typ: "select", operation: "SELECT",
+				text:    "SELECT * FROM users WHERE id = $1",
+				generic: "SELECT * FROM users WHERE id = $1",
+			},
+		},
+		{
+			name: "cte insert",
+			sql:  "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x",
+			want: statement{
+				typ: "insert", operation: "INSERT",
+				text:    "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x",
+				generic: "WITH x AS (SELECT ?) INSERT INTO t SELECT * FROM x",
+			},
+		},
+		{
+			name: "cte select",
+			sql:  "with recent as (select * from orders where total > 10) select count(*) from recent",
+			want: statement{
+				typ: "select", operation: "SELECT",
+				text:    "with recent as (select * from orders where total > 10) select count(*) from recent",
+				generic: "with recent as (select * from orders where total > ?) select count(*) from recent",
+			},
+		},
+		{
+			name: "dollar quoted and escape strings",
+			sql:  `SELECT $$it's$$, $tag$a $$ b$tag$, E'a\'b' FROM t`,
+			want: statement{
+				typ: "select", operation: "SELECT",
+				text:    `SELECT $$it's$$, $tag$a $$ b$tag$, E'a\'b' FROM t`,
+				generic: "SELECT ?, ?, ? FROM t",
+			},
+		},
+		{
+			name: "comment markers in strings",
+			sql:  "SELECT 'x -- y', '/* z */' FROM t -- trailing",
+			want: statement{
+				typ: "select", operation: "SELECT",
+				text:    "SELECT 'x -- y', '/* z */' FROM t",
+				generic: "SELECT ?, ? FROM t",
+			},
+		},
+		{
+			name: "nested block comment",
+			sql:  "SELECT 1 /* outer /* inner */ still comment */ + 2",
+			want: statement{
+				typ: "select", operation: "SELECT",
+				text:    "SELECT 1 + 2",
+				generic: "SELECT ? + ?",
+			},
+		},
+		{
+			name: "lists collapse",
+			sql:  "select * from t where id in ($1, $2, $3) and k in (1,2) and f(a, b)",
+			want: statement{
+				typ: "select", operation: "SELECT",
+				text:    "select * from t where id in ($1, $2, $3) and k in (1,2) and f(a, b)",
+				generic: "select * from t where id in (...) and k in (...) and f(a, b)",
+			},
+		},
+		{
+			name: "whitespace collapses",
+			sql:  "  UPDATE\tusers\n   SET name = $1\r\n WHERE id = $2 ",
+			want: statement{
+				typ: "update", operation: "UPDATE",
+				text:    "UPDATE users SET name = $1 WHERE id = $2",
+				generic: "UPDATE users SET name = $1 WHERE id = $2",
+			},
+		},
+		{
+			name: "merge",
+			sql:  "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET v = s.v",
+			want: statement{
+				typ: "merge", operation: "MERGE",
+				text:    "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET v = s.v",
+				generic: "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET v = s.v",
+			},
+		},
+		{
+			name: "copy",
+			sql:  "COPY t (a, b) FROM STDIN",
+			want: statement{
+				typ: "copy", operation: "COPY",
+				text:    "COPY t (a, b) FROM STDIN",
+				generic: "COPY t (a, b) FROM STDIN",
+			},
+		},
+		{
+			name: "ddl",
+			sql:  "truncate table sessions",
+			want: statement{
+				typ: "ddl", operation: "TRUNCATE",
+				text:    "truncate table sessions",
+				generic: "truncate table sessions",
+			},
+		},
+		{
+			name: "other",
+			sql:  "VACUUM ANALYZE users",
+			want: statement{
+				typ: "other", operation: "VACUUM",
+				text:    "VACUUM ANALYZE users",
+				generic: "VACUUM ANALYZE users",
+			},
+		},
+		{
+			name: "empty",
+			sql:  " -- nothing\n",
+			want: statement{typ: "other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseStatement(tt.sql)
+			got.fingerprint = ""
+
+			if got != tt.want {
+				t.Errorf("parseStatement(%q)\n got %+v\nwant %+v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	same := [][2]string{
+		{"SELECT * FROM t WHERE id = 1", "select  *\nfrom t where id = 42"},
+		{"SELECT * FROM t WHERE name = 'a'", "SELECT * FROM t WHERE name = $$b$$"},
+		{"SELECT * FROM t WHERE id IN ($1, $2)", "SELECT * FROM t WHERE id IN ($1, $2, $3, $4)"},
+		{"SELECT * FROM t WHERE id IN (1, 2)", "SELECT * FROM t WHERE id IN ($1)"},
+		{"SELECT 1;", "SELECT 1"},
+		{"SELECT 1 /* a /* b */ c */", "-- name: One :one\nSELECT 1"},
+	}
+	for _, p := range same {
+		if a, b := Fingerprint(p[0]), Fingerprint(p[1]); a != b {
+			t.Errorf("Fingerprint(%q) = %s, Fingerprint(%q) = %s, want equal", p[0], a, p[1], b)
+		}
+	}
+
+	different := [][2]string{
+		{"SELECT * FROM t", "SELECT * FROM u"},
+		{`SELECT * FROM "T"`, `SELECT * FROM "t"`},
+		{"SELECT a FROM t", "SELECT a, b FROM t"},
+		{"SELECT 1;", "SELECT 1; SELECT 1"},
+		{"SELECT f(a, b)", "SELECT f(a)"},
+	}
+	for _, p := range different {
+		if a, b := Fingerprint(p[0]), Fingerprint(p[1]); a == b {
+			t.Errorf("Fingerprint(%q) = Fingerprint(%q) = %s, want different", p[0], p[1], a)
+		}
+	}
+}
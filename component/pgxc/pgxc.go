@@ -2,26 +2,67 @@ package pgxc
 
 import (
 	"context"
+	"io/fs"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/tracelog"
 	sctx "github.com/phathdt/service-context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PgxComp interface {
 	GetConn() *pgxpool.Pool
+	GetTxManager() TxManager
+	// GetMigrator returns nil unless the component was created with
+	// WithMigrations.
+	GetMigrator() Migrator
+	// GetQueryStats returns the durations of the queries run so far, or nil
+	// unless the component was created with WithQueryStats.
+	GetQueryStats() *QueryStats
+	// GetQueryMetrics returns the duration histograms of the queries by
+	// statement type, or nil unless the component was created with
+	// WithQueryMetrics.
+	GetQueryMetrics() *QueryMetrics
 }
 
 type pgxComp struct {
 	id     string
 	prefix string
 	dsn    string
+	cfg    Config
 	logger sctx.Logger
 	pool   *pgxpool.Pool
+	tx     *txManager
+
+	migrations fs.FS
+	migrator   Migrator
+	stats      *QueryStats
+	metrics    *QueryMetrics
+
+	tracerProvider trace.TracerProvider
 }
 
-func New(id string, prefix string, dsn string) *pgxComp {
-	return &pgxComp{id: id, prefix: prefix, dsn: dsn}
+// New returns a pgx pool component. prefix is the logger prefix, defaulting
+// to id.
+func New(id string, prefix string, dsn string, opts ...Option) *pgxComp {
+	p := &pgxComp{id: id, prefix: prefix, dsn: dsn}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.cfg.QueryStats {
+		p.stats = newQueryStats(newRedactor(p.cfg))
+	}
+
+	if p.cfg.QueryMetrics {
+		p.metrics = &QueryMetrics{}
+	}
+
+	return p
 }
 
 func (p *pgxComp) ID() string {
@@ -29,24 +70,16 @@ func (p *pgxComp) ID() string {
 }
 
 func (p *pgxComp) Activate(_ sctx.ServiceContext) error {
-	p.logger = sctx.GlobalLogger().GetLogger(p.id)
-
-	p.logger.Info("Connecting to database...")
-
-	config, err := pgxpool.ParseConfig(p.dsn)
-	if err != nil {
-		p.logger.Error("Cannot parse dsn", err.Error())
-		return err
+	prefix := p.prefix
+	if prefix == "" {
+		prefix = p.id
 	}
+	p.logger = sctx.GlobalLogger().GetLogger(prefix)
 
-	config.ConnConfig.Tracer = &tracelog.TraceLog{
-		Logger:   &PgxLogAdapter{logger: p.logger},
-		LogLevel: tracelog.LogLevelDebug,
-	}
+	p.logger.Info("Connecting to database...")
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	pool, err := p.newPool(p.dsn, p.logger)
 	if err != nil {
-		p.logger.Error("Unable to connect to database", err.Error())
 		return err
 	}
 
@@ -56,10 +89,83 @@ func (p *pgxComp) Activate(_ sctx.ServiceContext) error {
 	}
 
 	p.pool = pool
+	p.tx = newTxManager(pool, p.logger.Named("tx"), p.cfg)
+
+	if p.migrations != nil {
+		cfg := MigrateConfig{Table: p.cfg.MigrationsTable, DryRun: p.cfg.MigrateDryRun}
+		p.migrator = NewMigrator(pool, p.migrations, cfg, p.logger.Named("migrate"))
+
+		if p.cfg.MigrateOnActivate {
+			if _, err = p.migrator.Up(context.Background()); err != nil {
+				p.logger.WithError(err).Error("Unable to migrate database")
+				return err
+			}
+		}
+	}
 
 	return nil
 }
 
+// newPool creates a pool for dsn with the component settings, tracing
+// queries to logger. It doesn't wait for a connection.
+func (p *pgxComp) newPool(dsn string, logger sctx.Logger) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		logger.Error("Cannot parse dsn", err.Error())
+		return nil, err
+	}
+
+	if err = p.cfg.apply(config); err != nil {
+		logger.WithError(err).Error("Invalid pool config")
+		return nil, err
+	}
+
+	logLevel, err := p.cfg.logLevel()
+	if err != nil {
+		logger.WithError(err).Error("Invalid pool config")
+		return nil, err
+	}
+
+	redact := newRedactor(p.cfg)
+
+	// Stats, metrics and the slow query log need every successful query,
+	// which pgx traces at info.
+	traceLevel := logLevel
+	if p.cfg.QueryStats || p.cfg.QueryMetrics || p.cfg.SlowQueryThreshold > 0 {
+		traceLevel = max(logLevel, tracelog.LogLevelInfo)
+	}
+
+	var tracer pgx.QueryTracer = &tracelog.TraceLog{
+		Logger: &PgxLogAdapter{
+			logger:        logger,
+			level:         logLevel,
+			stats:         p.stats,
+			metrics:       p.metrics,
+			slowThreshold: p.cfg.SlowQueryThreshold,
+			redact:        redact,
+		},
+		LogLevel: traceLevel,
+	}
+
+	if p.cfg.Tracing {
+		tp := p.tracerProvider
+		if tp == nil {
+			tp = otel.GetTracerProvider()
+		}
+		tracer = multitracer.New(tracer, newQueryTracer(tp, config.ConnConfig.Database, redact))
+	}
+
+	config.ConnConfig.Tracer = tracer
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		logger.Error("Unable to connect to database", err.Error())
+		return nil, err
+	}
+
+	return pool, nil
+}
+
 func (p *pgxComp) Stop() error {
 	p.pool.Close()
 	return nil
@@ -68,3 +174,19 @@ func (p *pgxComp) Stop() error {
 func (p *pgxComp) GetConn() *pgxpool.Pool {
 	return p.pool
 }
+
+func (p *pgxComp) GetTxManager() TxManager {
+	return p.tx
+}
+
+func (p *pgxComp) GetMigrator() Migrator {
+	return p.migrator
+}
+
+func (p *pgxComp) GetQueryStats() *QueryStats {
+	return p.stats
+}
+
+func (p *pgxComp) GetQueryMetrics() *QueryMetrics {
+	return p.metrics
+}
@@ -0,0 +1,190 @@
+// Package pgxprom exposes the pool statistics and query durations of a pgxc
+// component as Prometheus metrics, labelled with the component ID so the
+// saturation of each database can be told apart.
+//
+//	db := pgxc.New("postgres", "", dsn, pgxc.WithQueryMetrics())
+//	registry.MustRegister(pgxprom.NewCollector(db))
+package pgxprom
+
+import (
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/phathdt/service-context/component/pgxc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type options struct {
+	namespace   string
+	constLabels prometheus.Labels
+}
+
+// Option configures the collector returned by NewCollector.
+type Option func(*options)
+
+// WithNamespace prefixes the metric names with namespace.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// Component is implemented by the components returned by pgxc.New and
+// pgxc.NewReplicated.
+type Component interface {
+	ID() string
+	pgxc.PgxComp
+}
+
+// Collector is a prometheus.Collector reading the pool statistics and query
+// metrics of a component on every scrape.
+type Collector struct {
+	comp Component
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquires          *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	emptyAcquires     *prometheus.Desc
+	queryDuration     *prometheus.Desc
+	queryErrors       *prometheus.Desc
+}
+
+// NewCollector returns a collector exporting
+//
+//	pgxpool_acquired_conns{component, pool}
+//	pgxpool_idle_conns{component, pool}
+//	pgxpool_constructing_conns{component, pool}
+//	pgxpool_total_conns{component, pool}
+//	pgxpool_max_conns{component, pool}
+//	pgxpool_acquires_total{component, pool}
+//	pgxpool_acquire_duration_seconds_total{component, pool}
+//	pgxpool_canceled_acquires_total{component, pool}
+//	pgxpool_empty_acquires_total{component, pool}
+//	pgx_query_duration_seconds{component, operation}
+//	pgx_query_errors_total{component, operation}
+//
+// where pool is "primary", or "replica_<n>" for the replicas of a
+// pgxc.ReplicatedComp, and operation is the statement type: "select",
+// "insert", "update", "delete", "merge", "copy", "ddl" or "other". The
+// query metrics are only exported for components created with
+// pgxc.WithQueryMetrics, as the others don't time their queries. Register
+// one collector per component.
+func NewCollector(comp Component, opts ...Option) *Collector {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	labels := prometheus.Labels{"component": comp.ID()}
+	for k, v := range o.constLabels {
+		labels[k] = v
+	}
+
+	pool := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, "pgxpool", name),
+			help, []string{"pool"}, labels,
+		)
+	}
+	query := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, "pgx", name),
+			help, []string{"operation"}, labels,
+		)
+	}
+
+	return &Collector{
+		comp:              comp,
+		acquiredConns:     pool("acquired_conns", "Number of connections currently acquired from the pool."),
+		idleConns:         pool("idle_conns", "Number of idle connections in the pool."),
+		constructingConns: pool("constructing_conns", "Number of connections being established."),
+		totalConns:        pool("total_conns", "Number of connections in the pool, acquired, idle or being established."),
+		maxConns:          pool("max_conns", "Maximum size of the pool."),
+		acquires:          pool("acquires_total", "Number of successful connection acquires."),
+		acquireDuration:   pool("acquire_duration_seconds_total", "Time spent in successful connection acquires."),
+		canceledAcquires:  pool("canceled_acquires_total", "Number of acquires canceled by their context."),
+		emptyAcquires:     pool("empty_acquires_total", "Number of successful acquires that waited for a connection."),
+		queryDuration:     query("query_duration_seconds", "Duration of the queries, by statement type."),
+		queryErrors:       query("query_errors_total", "Number of failed queries, by statement type."),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquires
+	ch <- c.acquireDuration
+	ch <- c.canceledAcquires
+	ch <- c.emptyAcquires
+	ch <- c.queryDuration
+	ch <- c.queryErrors
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	// The pools don't exist until the component is activated
+	if pool := c.comp.GetConn(); pool != nil {
+		c.collectPool(ch, pool, "primary")
+	}
+
+	if r, ok := c.comp.(pgxc.ReplicatedComp); ok {
+		for i, pool := range r.Replicas() {
+			c.collectPool(ch, pool, "replica_"+strconv.Itoa(i))
+		}
+	}
+
+	metrics := c.comp.GetQueryMetrics()
+	if metrics == nil {
+		return
+	}
+
+	bounds := make([]float64, len(pgxc.QueryDurationBuckets))
+	for i, b := range pgxc.QueryDurationBuckets {
+		bounds[i] = b.Seconds()
+	}
+
+	for op, s := range metrics.Operations() {
+		buckets := make(map[float64]uint64, len(bounds))
+		for i, n := range s.Buckets {
+			buckets[bounds[i]] = n
+		}
+
+		ch <- prometheus.MustNewConstHistogram(c.queryDuration, s.Count, s.Sum.Seconds(), buckets, op)
+		ch <- prometheus.MustNewConstMetric(c.queryErrors, prometheus.CounterValue, float64(s.Errors), op)
+	}
+}
+
+func (c *Collector) collectPool(ch chan<- prometheus.Metric, pool *pgxpool.Pool, name string) {
+	s := pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, name)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, name)
+	}
+
+	gauge(c.acquiredConns, float64(s.AcquiredConns()))
+	gauge(c.idleConns, float64(s.IdleConns()))
+	gauge(c.constructingConns, float64(s.ConstructingConns()))
+	gauge(c.totalConns, float64(s.TotalConns()))
+	gauge(c.maxConns, float64(s.MaxConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.acquireDuration, s.AcquireDuration().Seconds())
+	counter(c.canceledAcquires, float64(s.CanceledAcquireCount()))
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+}
@@ -0,0 +1,89 @@
+package pgxprom_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/phathdt/service-context/component/pgxc"
+	"github.com/phathdt/service-context/component/pgxc/pgxprom"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubComponent is a component that was never activated, so it has no pool.
+type stubComponent struct {
+	metrics *pgxc.QueryMetrics
+}
+
+func (c *stubComponent) ID() string                          { return "db" }
+func (c *stubComponent) GetConn() *pgxpool.Pool              { return nil }
+func (c *stubComponent) GetTxManager() pgxc.TxManager        { return nil }
+func (c *stubComponent) GetMigrator() pgxc.Migrator          { return nil }
+func (c *stubComponent) GetQueryStats() *pgxc.QueryStats     { return nil }
+func (c *stubComponent) GetQueryMetrics() *pgxc.QueryMetrics { return c.metrics }
+
+func TestCollectorQueryMetrics(t *testing.T) {
+	metrics := &pgxc.QueryMetrics{}
+	metrics.Observe("select", 3*time.Millisecond, false)
+	metrics.Observe("select", 40*time.Millisecond, true)
+	metrics.Observe("insert", 20*time.Second, false)
+
+	c := pgxprom.NewCollector(&stubComponent{metrics: metrics}, pgxprom.WithNamespace("app"))
+
+	want := `
+# HELP app_pgx_query_errors_total Number of failed queries, by statement type.
+# TYPE app_pgx_query_errors_total counter
+app_pgx_query_errors_total{component="db",operation="insert"} 0
+app_pgx_query_errors_total{component="db",operation="select"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "app_pgx_query_errors_total"); err != nil {
+		t.Error(err)
+	}
+
+	want = `
+# HELP app_pgx_query_duration_seconds Duration of the queries, by statement type.
+# TYPE app_pgx_query_duration_seconds histogram
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.001"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.005"} 1
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.01"} 1
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.025"} 1
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.05"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.1"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.25"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="0.5"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="1"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="2.5"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="5"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="10"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="select",le="+Inf"} 2
+app_pgx_query_duration_seconds_sum{component="db",operation="select"} 0.043
+app_pgx_query_duration_seconds_count{component="db",operation="select"} 2
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.001"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.005"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.01"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.025"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.05"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.1"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.25"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="0.5"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="1"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="2.5"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="5"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="10"} 0
+app_pgx_query_duration_seconds_bucket{component="db",operation="insert",le="+Inf"} 1
+app_pgx_query_duration_seconds_sum{component="db",operation="insert"} 20
+app_pgx_query_duration_seconds_count{component="db",operation="insert"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "app_pgx_query_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorWithoutQueryMetrics(t *testing.T) {
+	c := pgxprom.NewCollector(&stubComponent{})
+
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("collected %d metrics from an inactive component without query metrics, want 0", n)
+	}
+}
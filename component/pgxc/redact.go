@@ -0,0 +1,224 @@
+package pgxc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// comparedColumnRe matches a column compared with a placeholder, as in
+	// "email = $1", "u.email ILIKE $2", "id IN ($3)" or "SET col = $4". The
+	// column and the placeholder may be wrapped in function calls taking
+	// them as their only argument, as in "lower(email) = lower($5)". Columns
+	// in other expressions, such as "coalesce(email, '') = $6", are not
+	// recognized; redact those with RedactArgPositions.
+	comparedColumnRe = regexp.MustCompile(`(?i)(?:[a-z_]\w*\s*\(\s*)*"?([a-z_][\w.]*)"?\s*\)*\s*(?:=|<>|!=|<=|>=|<|>|\bi?like\b|\bin\b)\s*(?:[a-z_]\w*\s*\(\s*|\(\s*)*\$(\d+)`)
+	insertRe         = regexp.MustCompile(`(?is)\binsert\s+into\s+[^(]+\(([^)]*)\)\s*values\s*(.*)`)
+	placeholderRe    = regexp.MustCompile(`\$(\d+)`)
+)
+
+// redactor hides and shortens the SQL and arguments of logged queries.
+type redactor struct {
+	all       bool
+	positions map[int]bool
+	columns   []string
+	maxSQL    int
+	maxArg    int
+}
+
+func newRedactor(cfg Config) *redactor {
+	r := &redactor{
+		all:    cfg.RedactArgs,
+		maxSQL: cfg.MaxSQLLength,
+		maxArg: cfg.MaxArgLength,
+	}
+
+	if len(cfg.RedactArgPositions) > 0 {
+		r.positions = make(map[int]bool, len(cfg.RedactArgPositions))
+		for _, pos := range cfg.RedactArgPositions {
+			r.positions[pos] = true
+		}
+	}
+
+	for _, c := range cfg.RedactColumns {
+		r.columns = append(r.columns, strings.ToLower(c))
+	}
+
+	return r
+}
+
+func (r *redactor) sql(sql string) string {
+	if r == nil {
+		return sql
+	}
+
+	return truncate(sql, r.maxSQL)
+}
+
+// args returns a copy of the arguments of sql with the redacted ones
+// replaced and the long ones truncated.
+func (r *redactor) args(sql string, args []any) []any {
+	if r == nil {
+		return args
+	}
+
+	if r.all {
+		out := make([]any, len(args))
+		for i := range out {
+			out[i] = redacted
+		}
+		return out
+	}
+
+	var sensitive map[int]bool
+	if len(r.columns) > 0 {
+		sensitive = r.sensitivePositions(sql)
+	}
+
+	out := make([]any, len(args))
+	for i, a := range args {
+		pos := i + 1
+		if r.positions[pos] || sensitive[pos] {
+			out[i] = redacted
+			continue
+		}
+		out[i] = r.arg(a)
+	}
+
+	return out
+}
+
+func (r *redactor) arg(a any) any {
+	if r.maxArg <= 0 {
+		return a
+	}
+
+	switch v := a.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return a
+	case string:
+		return truncate(v, r.maxArg)
+	case []byte:
+		return truncate(hex.EncodeToString(v), r.maxArg)
+	}
+
+	// Named byte slices such as json.RawMessage are logged as text
+	rv := reflect.ValueOf(a)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		if b := rv.Bytes(); utf8.Valid(b) {
+			return truncate(string(b), r.maxArg)
+		}
+		return truncate(hex.EncodeToString(rv.Bytes()), r.maxArg)
+	}
+
+	return truncate(fmt.Sprint(a), r.maxArg)
+}
+
+// sensitivePositions guesses the column of each placeholder of sql and
+// returns the positions bound to a column matching one of r.columns.
+func (r *redactor) sensitivePositions(sql string) map[int]bool {
+	positions := make(map[int]bool)
+
+	mark := func(column, placeholder string) {
+		if !r.sensitive(column) {
+			return
+		}
+		if pos, err := strconv.Atoi(placeholder); err == nil {
+			positions[pos] = true
+		}
+	}
+
+	for _, m := range comparedColumnRe.FindAllStringSubmatch(sql, -1) {
+		mark(m[1], m[2])
+	}
+
+	if m := insertRe.FindStringSubmatch(sql); m != nil {
+		columns := strings.Split(m[1], ",")
+		for _, row := range valueRows(m[2]) {
+			for i, expr := range row {
+				if i >= len(columns) {
+					break
+				}
+				for _, p := range placeholderRe.FindAllStringSubmatch(expr, -1) {
+					mark(columns[i], p[1])
+				}
+			}
+		}
+	}
+
+	return positions
+}
+
+func (r *redactor) sensitive(column string) bool {
+	column = strings.ToLower(strings.Trim(strings.TrimSpace(column), `"`))
+	if i := strings.LastIndexByte(column, '.'); i >= 0 {
+		column = column[i+1:]
+	}
+
+	for _, c := range r.columns {
+		if strings.Contains(column, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// valueRows splits the tuples of a VALUES list into their expressions,
+// stopping at the first clause following the list.
+func valueRows(values string) [][]string {
+	var (
+		rows  [][]string
+		row   []string
+		depth int
+		start int
+	)
+
+	for i, c := range values {
+		switch c {
+		case '(':
+			if depth == 0 {
+				row, start = nil, i+1
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				rows = append(rows, append(row, values[start:i]))
+			}
+		case ',':
+			if depth == 1 {
+				row = append(row, values[start:i])
+				start = i + 1
+			}
+		default:
+			if depth == 0 && !strings.ContainsRune(" \t\r\n,", c) {
+				return rows
+			}
+		}
+	}
+
+	return rows
+}
+
+// truncate cuts s to at most n bytes on a rune boundary, as pgx does for
+// long arguments.
+func truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+
+	l := n
+	for l > 0 && !utf8.RuneStart(s[l]) {
+		l--
+	}
+
+	return fmt.Sprintf("%s (truncated %d bytes)", s[:l], len(s)-l)
+}
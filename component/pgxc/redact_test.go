@@ -0,0 +1,104 @@
+package pgxc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSensitivePositions(t *testing.T) {
+	r := newRedactor(Config{RedactColumns: []string{"email", "Password"}})
+
+	tests := []struct {
+		sql  string
+		want []int
+	}{
+		{"SELECT * FROM users WHERE email = $1 AND id = $2", []int{1}},
+		{"SELECT * FROM users u WHERE u.email ILIKE $2", []int{2}},
+		{`SELECT * FROM users WHERE "Email" = $1`, []int{1}},
+		{"SELECT * FROM users WHERE email IN ($1, $2)", []int{1}},
+		{"SELECT * FROM users WHERE email = ANY($3)", []int{3}},
+		{"SELECT * FROM users WHERE lower(email) = $1", []int{1}},
+		{"SELECT * FROM users WHERE lower(trim(u.email)) = lower($2)", []int{2}},
+		{"SELECT * FROM users WHERE id = $1 AND lower(name) = $2", nil},
+		{"UPDATE users SET password_hash = $1, name = $2 WHERE id = $3", []int{1}},
+		{"INSERT INTO users (name, email, password) VALUES ($1, $2, $3)", []int{2, 3}},
+		{"INSERT INTO users (email, name) VALUES ($1, $2), ($3, $4) RETURNING id", []int{1, 3}},
+		{"INSERT INTO users (name, email) VALUES ($1, lower($2)) ON CONFLICT DO NOTHING", []int{2}},
+		// Not recognized: the column is one of several arguments
+		{"SELECT * FROM users WHERE coalesce(email, '') = $1", nil},
+	}
+
+	for _, tt := range tests {
+		got := r.sensitivePositions(tt.sql)
+
+		want := make(map[int]bool)
+		for _, pos := range tt.want {
+			want[pos] = true
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sensitivePositions(%q) = %v, want %v", tt.sql, got, want)
+		}
+	}
+}
+
+func TestValueRows(t *testing.T) {
+	tests := []struct {
+		values string
+		want   [][]string
+	}{
+		{"($1, $2)", [][]string{{"$1", " $2"}}},
+		{"($1, now()), ($2, f(a, b)) RETURNING id", [][]string{{"$1", " now()"}, {"$2", " f(a, b)"}}},
+		{"\n  ($1)\n, ($2)", [][]string{{"$1"}, {"$2"}}},
+		{"DEFAULT VALUES", nil},
+	}
+
+	for _, tt := range tests {
+		if got := valueRows(tt.values); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("valueRows(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 5, "hello (truncated 6 bytes)"},
+		// "é" is two bytes and is not split
+		{"café au lait", 4, "caf (truncated 10 bytes)"},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	sql := "SELECT * FROM users WHERE lower(email) = $1 AND name = $2 AND id = $3"
+	args := []any{"bob@example.com", "Robert Paulson", 7}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []any
+	}{
+		{"none", Config{}, []any{"bob@example.com", "Robert Paulson", 7}},
+		{"all", Config{RedactArgs: true}, []any{redacted, redacted, redacted}},
+		{"positions", Config{RedactArgPositions: []int{2}}, []any{"bob@example.com", redacted, 7}},
+		{"columns", Config{RedactColumns: []string{"email"}}, []any{redacted, "Robert Paulson", 7}},
+		{"length", Config{MaxArgLength: 6}, []any{"bob@ex (truncated 9 bytes)", "Robert (truncated 8 bytes)", 7}},
+	}
+
+	for _, tt := range tests {
+		if got := newRedactor(tt.cfg).args(sql, args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: args = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
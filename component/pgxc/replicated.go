@@ -0,0 +1,244 @@
+package pgxc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	sctx "github.com/phathdt/service-context"
+)
+
+const (
+	ReplicaRoundRobin = "round_robin"
+	ReplicaLeastConns = "least_conns"
+
+	defaultReplicaCheckPeriod = 5 * time.Second
+	replicaPingTimeout        = 2 * time.Second
+)
+
+// ReplicatedComp is a PgxComp whose GetConn, transactions and migrations use
+// the primary, with reads spread over replicas.
+type ReplicatedComp interface {
+	PgxComp
+	Primary() *pgxpool.Pool
+	// Replica returns a healthy replica, or the primary when the context was
+	// marked with UsePrimary, carries a transaction of this component, or no
+	// replica is healthy.
+	Replica(ctx context.Context) *pgxpool.Pool
+	// Replicas returns every replica pool, healthy or not, in the order of
+	// the DSNs.
+	Replicas() []*pgxpool.Pool
+}
+
+type primaryKey struct{}
+
+// UsePrimary marks ctx so Replica returns the primary, e.g. to read a row
+// right after writing it.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usesPrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	logger  sctx.Logger
+	healthy atomic.Bool
+}
+
+type replicatedComp struct {
+	*pgxComp
+	replicaDSNs []string
+	// replicas is set once by Activate and never modified afterwards, so it
+	// is read without locking.
+	replicas []*replica
+	next     atomic.Uint64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReplicated returns a component with a primary and read replicas. The
+// options apply to every pool.
+func NewReplicated(id string, prefix string, primaryDSN string, replicaDSNs []string, opts ...Option) *replicatedComp {
+	return &replicatedComp{
+		pgxComp:     New(id, prefix, primaryDSN, opts...),
+		replicaDSNs: replicaDSNs,
+	}
+}
+
+func (p *replicatedComp) Activate(sc sctx.ServiceContext) error {
+	switch p.cfg.ReplicaSelection {
+	case "", ReplicaRoundRobin, ReplicaLeastConns:
+	default:
+		return fmt.Errorf("invalid replica selection %q", p.cfg.ReplicaSelection)
+	}
+
+	if err := p.pgxComp.Activate(sc); err != nil {
+		return err
+	}
+
+	base := p.logger.Named("replica")
+	replicas := make([]*replica, 0, len(p.replicaDSNs))
+	for i, dsn := range p.replicaDSNs {
+		logger := base.With("replica", i)
+
+		pool, err := p.newPool(dsn, logger)
+		if err != nil {
+			closeReplicas(replicas)
+			_ = p.pgxComp.Stop()
+			return err
+		}
+
+		r := &replica{pool: pool, logger: logger}
+		replicas = append(replicas, r)
+
+		if err := p.check(r); err != nil {
+			logger.WithError(err).Warn("Replica is unreachable, reading from other replicas")
+		}
+	}
+	p.replicas = replicas
+
+	p.done = make(chan struct{})
+	p.wg.Add(1)
+	go p.healthLoop()
+
+	return nil
+}
+
+func (p *replicatedComp) Stop() error {
+	if p.done != nil {
+		close(p.done)
+		p.wg.Wait()
+		p.done = nil
+	}
+
+	closeReplicas(p.replicas)
+
+	return p.pgxComp.Stop()
+}
+
+// closeReplicas closes the pools of replicas. Closed pools stay selectable,
+// so queries racing Stop fail instead of reading a slice being modified.
+func closeReplicas(replicas []*replica) {
+	for _, r := range replicas {
+		r.pool.Close()
+	}
+}
+
+func (p *replicatedComp) Primary() *pgxpool.Pool {
+	return p.pool
+}
+
+func (p *replicatedComp) Replicas() []*pgxpool.Pool {
+	pools := make([]*pgxpool.Pool, len(p.replicas))
+	for i, r := range p.replicas {
+		pools[i] = r.pool
+	}
+
+	return pools
+}
+
+func (p *replicatedComp) Replica(ctx context.Context) *pgxpool.Pool {
+	if usesPrimary(ctx) {
+		return p.pool
+	}
+
+	if _, ok := p.tx.txFrom(ctx); ok {
+		return p.pool
+	}
+
+	var r *replica
+	if p.cfg.ReplicaSelection == ReplicaLeastConns {
+		r = p.leastConns()
+	} else {
+		r = p.roundRobin()
+	}
+
+	if r == nil {
+		return p.pool
+	}
+
+	return r.pool
+}
+
+func (p *replicatedComp) roundRobin() *replica {
+	n := uint64(len(p.replicas))
+	start := p.next.Add(1)
+
+	for i := uint64(0); i < n; i++ {
+		if r := p.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func (p *replicatedComp) leastConns() *replica {
+	var (
+		best  *replica
+		conns int32
+	)
+
+	for _, r := range p.replicas {
+		if !r.healthy.Load() {
+			continue
+		}
+
+		if n := r.pool.Stat().AcquiredConns(); best == nil || n < conns {
+			best, conns = r, n
+		}
+	}
+
+	return best
+}
+
+func (p *replicatedComp) healthLoop() {
+	defer p.wg.Done()
+
+	period := p.cfg.ReplicaCheckPeriod
+	if period <= 0 {
+		period = defaultReplicaCheckPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			for _, r := range p.replicas {
+				_ = p.check(r)
+			}
+		}
+	}
+}
+
+// check pings r, ejecting it from selection while it fails and logging when
+// its health changes.
+func (p *replicatedComp) check(r *replica) error {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+	defer cancel()
+
+	err := r.pool.Ping(ctx)
+	healthy := err == nil
+
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			r.logger.Info("Replica is healthy")
+		} else {
+			r.logger.WithError(err).Warn("Replica is unhealthy, reading from other replicas")
+		}
+	}
+
+	return err
+}
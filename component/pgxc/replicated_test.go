@@ -0,0 +1,108 @@
+package pgxc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	sctx "github.com/phathdt/service-context"
+)
+
+// unreachableDSN points at a closed port, so pings fail right away.
+const unreachableDSN = "postgres://user@127.0.0.1:1/db?connect_timeout=1"
+
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	pool, err := pgxpool.New(context.Background(), unreachableDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// newTestReplicated returns a component with n replicas, all healthy, that
+// never connects.
+func newTestReplicated(t *testing.T, selection string, n int) *replicatedComp {
+	t.Helper()
+
+	p := NewReplicated("db", "", unreachableDSN, nil, WithReplicaSelection(selection))
+	p.pool = newTestPool(t)
+
+	logger := sctx.GlobalLogger().GetLogger("db.replica")
+	for range n {
+		r := &replica{pool: newTestPool(t), logger: logger}
+		r.healthy.Store(true)
+		p.replicas = append(p.replicas, r)
+	}
+
+	return p
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	p := newTestReplicated(t, ReplicaRoundRobin, 3)
+	ctx := context.Background()
+
+	seen := make(map[*pgxpool.Pool]int)
+	for range 6 {
+		seen[p.Replica(ctx)]++
+	}
+
+	for i, r := range p.replicas {
+		if seen[r.pool] != 2 {
+			t.Errorf("replica %d selected %d times, want 2", i, seen[r.pool])
+		}
+	}
+}
+
+func TestReplicaSkipsUnhealthy(t *testing.T) {
+	for _, selection := range []string{ReplicaRoundRobin, ReplicaLeastConns} {
+		t.Run(selection, func(t *testing.T) {
+			p := newTestReplicated(t, selection, 3)
+			ctx := context.Background()
+
+			p.replicas[0].healthy.Store(false)
+			p.replicas[2].healthy.Store(false)
+
+			for range 4 {
+				if got := p.Replica(ctx); got != p.replicas[1].pool {
+					t.Fatalf("Replica() = %p, want the only healthy replica %p", got, p.replicas[1].pool)
+				}
+			}
+
+			p.replicas[1].healthy.Store(false)
+
+			if got := p.Replica(ctx); got != p.pool {
+				t.Errorf("Replica() = %p, want the primary %p when no replica is healthy", got, p.pool)
+			}
+		})
+	}
+}
+
+func TestReplicaUsePrimary(t *testing.T) {
+	p := newTestReplicated(t, ReplicaRoundRobin, 2)
+
+	if got := p.Replica(UsePrimary(context.Background())); got != p.pool {
+		t.Errorf("Replica(UsePrimary) = %p, want the primary %p", got, p.pool)
+	}
+}
+
+func TestReplicaCheckEjectsUnreachable(t *testing.T) {
+	p := newTestReplicated(t, ReplicaRoundRobin, 2)
+	ctx := context.Background()
+
+	if err := p.check(p.replicas[0]); err == nil {
+		t.Fatal("check() succeeded against an unreachable replica")
+	}
+	if p.replicas[0].healthy.Load() {
+		t.Fatal("unreachable replica is still healthy")
+	}
+
+	for range 4 {
+		if got := p.Replica(ctx); got != p.replicas[1].pool {
+			t.Fatalf("Replica() = %p, want the healthy replica %p", got, p.replicas[1].pool)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package pgxc
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxStatements bounds the number of statements tracked separately,
+	// later ones are aggregated under otherStatement.
+	maxStatements  = 1000
+	otherStatement = "(other)"
+	// statSamples is the number of recent durations kept per statement to
+	// estimate the 95th percentile.
+	statSamples = 128
+)
+
+// StatementStats aggregates the executions of one statement.
+type StatementStats struct {
+	// Name is the sqlc query name, or SQL when it has none.
+	Name string
+	// SQL is the statement with its literals replaced, truncated like the
+	// logged SQL.
+	SQL string
+	// Fingerprint identifies the statement, see Fingerprint.
+	Fingerprint string
+	Count       uint64
+	Errors      uint64
+	Total       time.Duration
+	Avg         time.Duration
+	// P95 is estimated from the most recent executions.
+	P95 time.Duration
+	Max time.Duration
+}
+
+type statementStats struct {
+	name        string
+	sql         string
+	fingerprint string
+	count       uint64
+	errors      uint64
+	total       time.Duration
+	max         time.Duration
+	samples     []time.Duration
+	next        int
+}
+
+// QueryStats collects per statement durations of the queries run through a
+// pgxc component.
+type QueryStats struct {
+	mu     sync.Mutex
+	stmts  map[string]*statementStats
+	redact *redactor
+}
+
+func newQueryStats(redact *redactor) *QueryStats {
+	return &QueryStats{
+		stmts:  make(map[string]*statementStats),
+		redact: redact,
+	}
+}
+
+// observe records an execution of stmt, keyed by its sqlc name or, without
+// one, its fingerprint.
+func (s *QueryStats) observe(stmt statement, d time.Duration, failed bool) {
+	sql := s.redact.sql(stmt.generic)
+
+	key, name := stmt.name, stmt.name
+	if key == "" {
+		key, name = stmt.fingerprint, sql
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stmts[key]
+	if !ok {
+		if len(s.stmts) >= maxStatements {
+			key, name, sql, stmt = otherStatement, otherStatement, "", statement{}
+			st = s.stmts[key]
+		}
+		if st == nil {
+			st = &statementStats{
+				name:        name,
+				sql:         sql,
+				fingerprint: stmt.fingerprint,
+				samples:     make([]time.Duration, 0, statSamples),
+			}
+			s.stmts[key] = st
+		}
+	}
+
+	st.count++
+	if failed {
+		st.errors++
+	}
+	st.total += d
+	st.max = max(st.max, d)
+
+	if len(st.samples) < statSamples {
+		st.samples = append(st.samples, d)
+	} else {
+		st.samples[st.next] = d
+		st.next = (st.next + 1) % statSamples
+	}
+}
+
+// Snapshot returns the stats of every statement, by name.
+func (s *QueryStats) Snapshot() []StatementStats {
+	s.mu.Lock()
+	out := make([]StatementStats, 0, len(s.stmts))
+	for _, st := range s.stmts {
+		out = append(out, st.snapshot())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+
+	return out
+}
+
+// TopN returns the n statements with the highest 95th percentile duration.
+func (s *QueryStats) TopN(n int) []StatementStats {
+	out := s.Snapshot()
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].P95 != out[j].P95 {
+			return out[i].P95 > out[j].P95
+		}
+		return out[i].Total > out[j].Total
+	})
+
+	if n >= 0 && n < len(out) {
+		out = out[:n]
+	}
+
+	return out
+}
+
+// Reset clears the statement stats.
+func (s *QueryStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stmts = make(map[string]*statementStats)
+}
+
+func (st *statementStats) snapshot() StatementStats {
+	out := StatementStats{
+		Name:        st.name,
+		SQL:         st.sql,
+		Fingerprint: st.fingerprint,
+		Count:       st.count,
+		Errors:      st.errors,
+		Total:       st.total,
+		Max:         st.max,
+	}
+
+	if st.count > 0 {
+		out.Avg = st.total / time.Duration(st.count)
+	}
+
+	if len(st.samples) > 0 {
+		sorted := slices.Clone(st.samples)
+		slices.Sort(sorted)
+		out.P95 = sorted[(len(sorted)*95+99)/100-1]
+	}
+
+	return out
+}
@@ -0,0 +1,148 @@
+package pgxc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of the spans created by pgxc.
+const TracerName = "github.com/phathdt/service-context/component/pgxc"
+
+var (
+	dbSystem           = attribute.Key("db.system")
+	dbName             = attribute.Key("db.name")
+	dbStatement        = attribute.Key("db.statement")
+	dbOperation        = attribute.Key("db.operation")
+	dbSQLTable         = attribute.Key("db.sql.table")
+	dbBatchSize        = attribute.Key("db.batch.size")
+	dbRowsAffected     = attribute.Key("db.rows_affected")
+	dbQueryName        = attribute.Key("db.query.name")
+	serverAddress      = attribute.Key("server.address")
+	serverPort         = attribute.Key("server.port")
+	dbSystemPostgreSQL = dbSystem.String("postgresql")
+)
+
+// queryTracer creates a client span per query, batch, copy and connect, as
+// a child of the span in the context the query was issued with. Statements
+// are recorded with their literals replaced, see Fingerprint.
+type queryTracer struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+	redact *redactor
+}
+
+func newQueryTracer(tp trace.TracerProvider, database string, redact *redactor) *queryTracer {
+	attrs := []attribute.KeyValue{dbSystemPostgreSQL}
+	if database != "" {
+		attrs = append(attrs, dbName.String(database))
+	}
+
+	return &queryTracer{
+		tracer: tp.Tracer(TracerName),
+		attrs:  attrs,
+		redact: redact,
+	}
+}
+
+func (t *queryTracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	ctx, _ = t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attrs...),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx
+}
+
+// end ends the span started by start, marking it failed when err is set.
+func end(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statement returns the span name of stmt, its sqlc name or operation, and
+// its attributes.
+func (t *queryTracer) statement(stmt statement) (string, []attribute.KeyValue) {
+	attrs := []attribute.KeyValue{
+		dbStatement.String(t.redact.sql(stmt.generic)),
+		dbOperation.String(stmt.operation),
+	}
+
+	name := stmt.operation
+	if stmt.name != "" {
+		name = stmt.name
+		attrs = append(attrs, dbQueryName.String(stmt.name))
+	}
+	if name == "" {
+		name = "query"
+	}
+
+	return name, attrs
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	// Share the parsed statement with the query logger
+	stmt := parseStatement(data.SQL)
+	ctx = withStatement(ctx, data.SQL, stmt)
+
+	name, attrs := t.statement(stmt)
+	return t.start(ctx, name, attrs...)
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if data.Err == nil {
+		trace.SpanFromContext(ctx).SetAttributes(dbRowsAffected.Int64(data.CommandTag.RowsAffected()))
+	}
+	end(ctx, data.Err)
+}
+
+// TraceBatchStart starts one span for the batch, its queries are recorded
+// as events as pgx doesn't time them separately.
+func (t *queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx, "BATCH", dbOperation.String("BATCH"), dbBatchSize.Int(data.Batch.Len()))
+}
+
+func (t *queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	_, attrs := t.statement(parseStatement(data.SQL))
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error", data.Err.Error()))
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (t *queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	end(ctx, data.Err)
+}
+
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := strings.Join(data.TableName, ".")
+	return t.start(ctx, "COPY "+table, dbOperation.String("COPY"), dbSQLTable.String(table))
+}
+
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if data.Err == nil {
+		trace.SpanFromContext(ctx).SetAttributes(dbRowsAffected.Int64(data.CommandTag.RowsAffected()))
+	}
+	end(ctx, data.Err)
+}
+
+func (t *queryTracer) TraceConnectStart(ctx context.Context, data pgx.TraceConnectStartData) context.Context {
+	return t.start(ctx, "CONNECT",
+		serverAddress.String(data.ConnConfig.Host),
+		serverPort.Int(int(data.ConnConfig.Port)),
+	)
+}
+
+func (t *queryTracer) TraceConnectEnd(ctx context.Context, data pgx.TraceConnectEndData) {
+	end(ctx, data.Err)
+}
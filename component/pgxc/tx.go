@@ -0,0 +1,247 @@
+package pgxc
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	sctx "github.com/phathdt/service-context"
+)
+
+// DBTX is implemented by both the pool and a transaction. It is a superset of
+// the DBTX interface sqlc generates for pgx/v5, so the result of
+// TxManager.DB can be passed to sqlc's New.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type TxManager interface {
+	// WithTx runs fn in a transaction carried by the context passed to it.
+	// The transaction commits when fn returns nil and rolls back when it
+	// returns an error or panics. Calls nested in fn use a savepoint, and
+	// ignore opts.
+	//
+	// A transaction failing with a serialization failure or deadlock is
+	// rolled back and fn is run again from the start, so fn must not have
+	// side effects outside the database.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error
+	// DB returns the transaction of ctx, or the pool outside WithTx.
+	DB(ctx context.Context) DBTX
+	// Stats returns the retry counters.
+	Stats() TxStats
+}
+
+// TxStats counts transaction retries since the component was activated.
+type TxStats struct {
+	// Retries is the number of times a transaction was run again.
+	Retries uint64
+	// Exhausted is the number of transactions that still failed with a
+	// retryable error after the last attempt.
+	Exhausted uint64
+}
+
+type txOptions struct {
+	pgx.TxOptions
+	maxAttempts int
+}
+
+type TxOption func(*txOptions)
+
+func WithIsolation(level pgx.TxIsoLevel) TxOption {
+	return func(o *txOptions) {
+		o.IsoLevel = level
+	}
+}
+
+func ReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.AccessMode = pgx.ReadOnly
+	}
+}
+
+// WithMaxAttempts overrides the number of attempts configured on the
+// component for one call. 1 disables retries.
+func WithMaxAttempts(n int) TxOption {
+	return func(o *txOptions) {
+		o.maxAttempts = n
+	}
+}
+
+const (
+	defaultTxMaxAttempts     = 3
+	defaultTxRetryBackoff    = 10 * time.Millisecond
+	defaultTxRetryMaxBackoff = time.Second
+)
+
+// retryableCodes are the SQLSTATEs after which the whole transaction can be
+// run again.
+var retryableCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+}
+
+// retryableCode returns the SQLSTATE of err when the transaction can be
+// retried.
+func retryableCode(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && retryableCodes[pgErr.Code] {
+		return pgErr.Code, true
+	}
+
+	return "", false
+}
+
+// txKey is scoped to a manager so transactions of different databases don't
+// mix.
+type txKey struct {
+	m *txManager
+}
+
+type txManager struct {
+	pool   *pgxpool.Pool
+	logger sctx.Logger
+
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+
+	retries   atomic.Uint64
+	exhausted atomic.Uint64
+}
+
+func newTxManager(pool *pgxpool.Pool, logger sctx.Logger, cfg Config) *txManager {
+	m := &txManager{
+		pool:        pool,
+		logger:      logger,
+		maxAttempts: cfg.TxMaxAttempts,
+		backoff:     cfg.TxRetryBackoff,
+		maxBackoff:  cfg.TxRetryMaxBackoff,
+	}
+
+	if m.maxAttempts <= 0 {
+		m.maxAttempts = defaultTxMaxAttempts
+	}
+	if m.backoff <= 0 {
+		m.backoff = defaultTxRetryBackoff
+	}
+	if m.maxBackoff <= 0 {
+		m.maxBackoff = defaultTxRetryMaxBackoff
+	}
+
+	return m
+}
+
+func (m *txManager) txFrom(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{m}).(pgx.Tx)
+	return tx, ok
+}
+
+func (m *txManager) DB(ctx context.Context) DBTX {
+	if tx, ok := m.txFrom(ctx); ok {
+		return tx
+	}
+
+	return m.pool
+}
+
+func (m *txManager) Stats() TxStats {
+	return TxStats{Retries: m.retries.Load(), Exhausted: m.exhausted.Load()}
+}
+
+func (m *txManager) WithTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	// Only the outermost transaction is retried, a savepoint can't recover
+	// from a serialization failure.
+	if outer, ok := m.txFrom(ctx); ok {
+		sp, err := outer.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		return m.run(ctx, sp, fn)
+	}
+
+	o := txOptions{maxAttempts: m.maxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.begin(ctx, o.TxOptions, fn)
+
+		code, retryable := retryableCode(err)
+		if !retryable {
+			return err
+		}
+
+		if attempt >= o.maxAttempts {
+			if o.maxAttempts > 1 {
+				m.exhausted.Add(1)
+				m.logger.WithContext(ctx).WithError(err).Warnw("Transaction retries exhausted", "attempts", attempt, "sqlstate", code)
+			}
+			return err
+		}
+
+		wait := m.backoffFor(attempt)
+		m.logger.WithContext(ctx).Infow("Retrying transaction", "attempt", attempt+1, "sqlstate", code, "backoff", wait)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		m.retries.Add(1)
+	}
+}
+
+// backoffFor returns the wait after the given attempt: exponential with
+// jitter, bounded by maxBackoff.
+func (m *txManager) backoffFor(attempt int) time.Duration {
+	d := m.backoff << (attempt - 1)
+	if d <= 0 || d > m.maxBackoff {
+		d = m.maxBackoff
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
+
+func (m *txManager) begin(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := m.pool.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	return m.run(ctx, tx, fn)
+}
+
+func (m *txManager) run(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			m.rollback(ctx, tx)
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{m}, tx)); err != nil {
+		m.rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// rollback rolls tx back even when ctx is already canceled.
+func (m *txManager) rollback(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		m.logger.WithContext(ctx).WithError(err).Warn("Cannot roll back transaction")
+	}
+}
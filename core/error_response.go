@@ -7,8 +7,10 @@ import (
 	stderr "errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 
+	"github.com/phathdt/service-context/internal/errinfo"
 	"github.com/pkg/errors"
 )
 
@@ -216,6 +218,42 @@ func (e DefaultError) Format(s fmt.State, verb rune) {
 	}
 }
 
+// LogValue implements slog.LogValuer so the error logs as a group with all of
+// its fields, the wrapped cause chain and the stack trace.
+func (e DefaultError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("message", e.ErrorField)}
+
+	if e.IDField != "" {
+		attrs = append(attrs, slog.String("id", e.IDField))
+	}
+	if e.CodeField != 0 {
+		attrs = append(attrs, slog.Int("code", e.CodeField))
+	}
+	if e.StatusField != "" {
+		attrs = append(attrs, slog.String("status", e.StatusField))
+	}
+	if e.RIDField != "" {
+		attrs = append(attrs, slog.String("request", e.RIDField))
+	}
+	if e.ReasonField != "" {
+		attrs = append(attrs, slog.String("reason", e.ReasonField))
+	}
+	if e.DebugField != "" {
+		attrs = append(attrs, slog.String("debug", e.DebugField))
+	}
+	if len(e.DetailsField) > 0 {
+		attrs = append(attrs, slog.Any("details", e.DetailsField))
+	}
+	if causes := errinfo.Causes(e); len(causes) > 0 {
+		attrs = append(attrs, slog.Any("causes", causes))
+	}
+	if frames := errinfo.StackFrames(e.err); len(frames) > 0 {
+		attrs = append(attrs, slog.Any("stack", frames))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
 func ToDefaultError(err error, requestID string) *DefaultError {
 	de := &DefaultError{
 		RIDField:     requestID,
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/joho/godotenv"
+	"github.com/phathdt/service-context/component/pgxc"
 	"github.com/spf13/viper"
 )
 
@@ -23,6 +24,8 @@ type DatabaseConfig struct {
 	Password string `yaml:"password" mapstructure:"password"`
 	Database string `yaml:"database" mapstructure:"database"`
 	SSLMode  string `yaml:"ssl_mode" mapstructure:"ssl_mode"`
+
+	Pool pgxc.Config `yaml:"pool" mapstructure:"pool"`
 }
 
 type RedisConfig struct {
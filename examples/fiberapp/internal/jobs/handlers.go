@@ -37,7 +37,7 @@ func (h *JobHandlers) HandleTodoCreated(ctx context.Context, t *asynq.Task) erro
 	// - Sync with external services
 	// - Generate reports
 	
-	h.logger.Info("TODO CREATED job processed successfully", "id", payload.ID)
+	h.logger.Infow("TODO CREATED job processed successfully", "id", payload.ID)
 	return nil
 }
 
@@ -52,7 +52,7 @@ func (h *JobHandlers) HandleTodoUpdated(ctx context.Context, t *asynq.Task) erro
 	h.logger.Info(fmt.Sprintf("Processing TODO UPDATED job - ID: %s, Title: %s, Completed: %t, UpdatedAt: %s",
 		payload.ID, payload.Title, payload.Completed, payload.UpdatedAt))
 
-	h.logger.Info("TODO UPDATED job processed successfully", "id", payload.ID)
+	h.logger.Infow("TODO UPDATED job processed successfully", "id", payload.ID)
 	return nil
 }
 
@@ -67,6 +67,6 @@ func (h *JobHandlers) HandleTodoDeleted(ctx context.Context, t *asynq.Task) erro
 	h.logger.Info(fmt.Sprintf("Processing TODO DELETED job - ID: %s, DeletedAt: %s",
 		payload.ID, payload.DeletedAt))
 
-	h.logger.Info("TODO DELETED job processed successfully", "id", payload.ID)
+	h.logger.Infow("TODO DELETED job processed successfully", "id", payload.ID)
 	return nil
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"fiberapp/internal/handler"
 	"fiberapp/internal/jobs"
 	"fiberapp/internal/service"
+	sqlfiles "fiberapp/sql"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
@@ -102,7 +104,10 @@ func main() {
 func NewServiceContextAndLoad(cfg *config.Config) sctx.ServiceContext {
 
 	// Create components with configuration passed directly in constructors
-	pgxComp := pgxc.New("postgres", "postgres", cfg.Database.GetDSN())
+	pgxComp := pgxc.New("postgres", "postgres", cfg.Database.GetDSN(),
+		pgxc.WithConfig(cfg.Database.Pool),
+		pgxc.WithMigrations(schemaFS()),
+	)
 	redisComp := redisc.New("redis", cfg.Redis.GetURI())
 	asynqClientComp := asynqc.New("asynq-client", cfg.Redis.GetURI())
 	asynqWorkerComp := asynqw.New("asynq-worker", cfg.Redis.GetURI())
@@ -211,3 +216,13 @@ func health() fiber.Handler {
 		})
 	}
 }
+
+// schemaFS returns the migrations under sql/schema.
+func schemaFS() fs.FS {
+	schema, err := fs.Sub(sqlfiles.Schema, "schema")
+	if err != nil {
+		panic(err)
+	}
+
+	return schema
+}
@@ -1,6 +1,10 @@
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
-CREATE TABLE todos (
+-- IF NOT EXISTS lets the migration run against databases created before
+-- migrations were tracked, when the schema was loaded by the container's
+-- initdb scripts.
+
+CREATE TABLE IF NOT EXISTS todos (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     title VARCHAR(255) NOT NULL,
     description TEXT,
@@ -9,5 +13,5 @@ CREATE TABLE todos (
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
-CREATE INDEX idx_todos_completed ON todos(completed);
-CREATE INDEX idx_todos_created_at ON todos(created_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_todos_completed ON todos(completed);
+CREATE INDEX IF NOT EXISTS idx_todos_created_at ON todos(created_at);
\ No newline at end of file
@@ -0,0 +1,9 @@
+package sql
+
+import "embed"
+
+// Schema holds the migrations under schema/, applied by the postgres
+// component on startup.
+//
+//go:embed schema/*.sql
+var Schema embed.FS
@@ -0,0 +1,84 @@
+// Package errinfo extracts the cause chain and stack trace of errors for
+// logging, shared by the logger and core.DefaultError.
+package errinfo
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+const (
+	maxErrorDepth  = 16
+	maxStackFrames = 32
+)
+
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// Causes returns the messages of the errors wrapped by err, outermost
+// first, skipping wrappers that don't change the message.
+func Causes(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	var causes []string
+
+	msg := err.Error()
+	cur := errors.Unwrap(err)
+	for i := 0; cur != nil && i < maxErrorDepth; i++ {
+		if m := cur.Error(); m != msg {
+			causes = append(causes, m)
+			msg = m
+		}
+		cur = errors.Unwrap(cur)
+	}
+
+	return causes
+}
+
+// StackFrames formats the stack trace recorded by github.com/pkg/errors
+// closest to the origin of err as "function file:line" entries.
+func StackFrames(err error) []string {
+	var trace pkgerrors.StackTrace
+
+	for i := 0; err != nil && i < maxErrorDepth; i++ {
+		if st, ok := err.(stackTracer); ok && len(st.StackTrace()) > 0 {
+			trace = st.StackTrace()
+		}
+		err = errors.Unwrap(err)
+	}
+
+	if len(trace) > maxStackFrames {
+		trace = trace[:maxStackFrames]
+	}
+
+	frames := make([]string, 0, len(trace))
+	for _, f := range trace {
+		pc := uintptr(f) - 1
+		fn := runtime.FuncForPC(pc)
+		if fn == nil {
+			continue
+		}
+		file, line := fn.FileLine(pc)
+		frames = append(frames, fmt.Sprintf("%s %s:%d", fn.Name(), TrimPath(file), line))
+	}
+
+	return frames
+}
+
+// TrimPath keeps the last directory and the file name.
+func TrimPath(file string) string {
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			return file[j+1:]
+		}
+	}
+
+	return file
+}
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/lmittmann/tint"
-	"github.com/mattn/go-isatty"
 )
 
 const RFC3339Milli = "2006-01-02T15:04:05.000Z07:00"
@@ -42,9 +41,23 @@ type Logger interface {
 	Panicln(...any)
 	Traceln(...any)
 
+	Debugw(msg string, keysAndValues ...any)
+	Infow(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+	Errorw(msg string, keysAndValues ...any)
+	Fatalw(msg string, keysAndValues ...any)
+	Panicw(msg string, keysAndValues ...any)
+	Tracew(msg string, keysAndValues ...any)
+	LogAttrs(level CustomLevel, msg string, attrs ...slog.Attr)
+
 	With(key string, value any) Logger
 	Withs(Fields) Logger
+	WithError(err error) Logger
+	WithContext(ctx context.Context) Logger
 	WithSrc() Logger
+	WithCallerSkip(n int) Logger
+	WithGroup(name string) Logger
+	Named(name string) Logger
 	GetLevel() string
 	GetFormat() string
 
@@ -105,92 +118,197 @@ func (l CustomLevel) Level() slog.Level {
 	}
 }
 
+// LevelOf returns the closest CustomLevel at or below a slog level, rounding
+// levels in between, such as those of records logged through slog directly,
+// down.
+func LevelOf(level slog.Level) CustomLevel {
+	for l := LevelPanic; l > LevelTrace; l-- {
+		if level >= l.Level() {
+			return l
+		}
+	}
+
+	return LevelTrace
+}
+
 type logger struct {
 	*slog.Logger
-	level  CustomLevel
-	format string
+	// root is the handler chain before the prefix attribute, and ops the
+	// With* calls applied since, so Named can rebuild the logger with a
+	// longer prefix that stays outside any group.
+	root    *slog.Logger
+	prefix  string
+	ops     []func(*slog.Logger) *slog.Logger
+	level   CustomLevel
+	format  string
+	flush   func()
+	ctx     context.Context
+	sources levelSet
+	theme   *Theme
+	// skip is the number of extra stack frames between the caller and the
+	// logging method, e.g. the global logger proxy or an adapter.
+	skip int
+}
+
+func (l *logger) with(sl *slog.Logger) *logger {
+	c := *l
+	c.Logger = sl
+	return &c
+}
+
+// derive returns a copy of l with op applied to its slog.Logger and recorded
+// for Named.
+func (l *logger) derive(op func(*slog.Logger) *slog.Logger) *logger {
+	c := l.with(op(l.Logger))
+	c.ops = append(l.ops[:len(l.ops):len(l.ops)], op)
+	return c
 }
 
 func (l *logger) GetLevel() string {
 	return l.level.String()
 }
 
+// GetTheme returns the colors of the text output, or nil when output isn't
+// colored.
+func (l *logger) GetTheme() *Theme {
+	return l.theme
+}
+
 func (l *logger) GetFormat() string {
 	return l.format
 }
 
-func (l *logger) debugSrc() *logger {
-	_, file, line, ok := runtime.Caller(2)
-	if !ok {
-		file = "<???>"
-		line = 1
-	} else {
-		slash := strings.LastIndex(file, "/")
-		file = file[slash+1:]
+// exit flushes queued records, runs the registered exit hooks and calls the
+// exit function. Records logged by hooks are flushed before exiting.
+func (l *logger) exit() {
+	l.flush()
+	runExitHooks()
+	l.flush()
+	exitFunc()(1)
+}
+
+// write must only be called from log, logf and logw, which must only be
+// called from the exported logging methods, so the caller is always the same
+// number of frames up. args are slog.Attr values or alternating keys and
+// values, as accepted by slog.Record.Add.
+func (l *logger) write(level CustomLevel, msg string, args ...any) {
+	var pc uintptr
+	if l.sources.has(level) {
+		var pcs [1]uintptr
+		// Skip runtime.Callers, write, log or logf and the exported method.
+		runtime.Callers(4+l.skip, pcs[:])
+		pc = pcs[0]
 	}
-	return &logger{l.Logger.With("source", fmt.Sprintf("%s:%d", file, line)), l.level, l.format}
+
+	r := slog.NewRecord(time.Now(), level.Level(), msg, pc)
+	r.Add(args...)
+	_ = l.Logger.Handler().Handle(l.ctx, r)
 }
 
 func (l *logger) log(level CustomLevel, args ...any) {
-	if !l.Logger.Enabled(context.Background(), level.Level()) {
+	if !l.Logger.Enabled(l.ctx, level.Level()) {
 		return
 	}
-	msg := fmt.Sprint(args...)
-	l.Logger.Log(context.Background(), level.Level(), msg)
+	if len(args) == 1 {
+		if err, ok := args[0].(error); ok {
+			l.write(level, err.Error(), ErrorAttr(err))
+			return
+		}
+	}
+	l.write(level, fmt.Sprint(args...))
 }
 
+// GetSLogger returns the underlying slog.Logger. Records logged through it
+// carry the caller for the levels configured with Config.SourceLevels.
 func (l *logger) GetSLogger() *slog.Logger {
-	return l.Logger
+	return slog.New(&sourceHandler{next: l.Logger.Handler(), sources: l.sources})
 }
-func (l *logger) Debug(args ...any) { l.debugSrc().log(LevelDebug, args...) }
+func (l *logger) Debug(args ...any) { l.log(LevelDebug, args...) }
 func (l *logger) Info(args ...any)  { l.log(LevelInfo, args...) }
 func (l *logger) Warn(args ...any)  { l.log(LevelWarn, args...) }
 func (l *logger) Error(args ...any) { l.log(LevelError, args...) }
-func (l *logger) Fatal(args ...any) { l.log(LevelFatal, args...); os.Exit(1) }
-func (l *logger) Panic(args ...any) { s := fmt.Sprint(args...); l.log(LevelPanic, s); panic(s) }
+func (l *logger) Fatal(args ...any) { l.log(LevelFatal, args...); l.exit() }
+func (l *logger) Panic(args ...any) {
+	s := fmt.Sprint(args...)
+	l.log(LevelPanic, s)
+	l.flush()
+	panic(s)
+}
 func (l *logger) Trace(args ...any) { l.log(LevelTrace, args...) }
 
 func (l *logger) logf(level CustomLevel, format string, args ...any) {
-	if !l.Logger.Enabled(context.Background(), level.Level()) {
+	if !l.Logger.Enabled(l.ctx, level.Level()) {
 		return
 	}
-	msg := fmt.Sprintf(format, args...)
-	l.Logger.Log(context.Background(), level.Level(), msg)
+	l.write(level, fmt.Sprintf(format, args...))
 }
 
-func (l *logger) Debugf(format string, args ...any) {
-	l.debugSrc().logf(LevelDebug, format, args...)
-}
+func (l *logger) Debugf(format string, args ...any) { l.logf(LevelDebug, format, args...) }
 func (l *logger) Infof(format string, args ...any)  { l.logf(LevelInfo, format, args...) }
 func (l *logger) Warnf(format string, args ...any)  { l.logf(LevelWarn, format, args...) }
 func (l *logger) Errorf(format string, args ...any) { l.logf(LevelError, format, args...) }
 func (l *logger) Fatalf(format string, args ...any) {
 	l.logf(LevelFatal, format, args...)
-	os.Exit(1)
+	l.exit()
 }
 func (l *logger) Panicf(format string, args ...any) {
 	s := fmt.Sprintf(format, args...)
 	l.logf(LevelPanic, "%s", s)
+	l.flush()
 	panic(s)
 }
 func (l *logger) Tracef(format string, args ...any) { l.logf(LevelTrace, format, args...) }
 
-func (l *logger) Debugln(args ...any) { l.Debug(args...) }
-func (l *logger) Infoln(args ...any)  { l.Info(args...) }
-func (l *logger) Warnln(args ...any)  { l.Warn(args...) }
-func (l *logger) Errorln(args ...any) { l.Error(args...) }
-func (l *logger) Fatalln(args ...any) {
-	l.Fatal(args...)
-	// Note: os.Exit(1) is called by l.Fatal, so it's not needed here
-}
+// The *ln methods call log directly rather than their counterparts to keep
+// the caller at the same stack depth.
+func (l *logger) Debugln(args ...any) { l.log(LevelDebug, args...) }
+func (l *logger) Infoln(args ...any)  { l.log(LevelInfo, args...) }
+func (l *logger) Warnln(args ...any)  { l.log(LevelWarn, args...) }
+func (l *logger) Errorln(args ...any) { l.log(LevelError, args...) }
+func (l *logger) Fatalln(args ...any) { l.log(LevelFatal, args...); l.exit() }
 func (l *logger) Panicln(args ...any) {
-	l.Panic(args...)
-	// Note: panic is called by l.Panic, so it's not needed here
+	s := fmt.Sprint(args...)
+	l.log(LevelPanic, s)
+	l.flush()
+	panic(s)
+}
+func (l *logger) Traceln(args ...any) { l.log(LevelTrace, args...) }
+
+func (l *logger) logw(level CustomLevel, msg string, keysAndValues ...any) {
+	if !l.Logger.Enabled(l.ctx, level.Level()) {
+		return
+	}
+	l.write(level, msg, keysAndValues...)
+}
+
+// The *w methods log msg with structured attributes given as alternating
+// keys and values or slog.Attr, like slog.Logger.Info.
+func (l *logger) Debugw(msg string, keysAndValues ...any) { l.logw(LevelDebug, msg, keysAndValues...) }
+func (l *logger) Infow(msg string, keysAndValues ...any)  { l.logw(LevelInfo, msg, keysAndValues...) }
+func (l *logger) Warnw(msg string, keysAndValues ...any)  { l.logw(LevelWarn, msg, keysAndValues...) }
+func (l *logger) Errorw(msg string, keysAndValues ...any) { l.logw(LevelError, msg, keysAndValues...) }
+func (l *logger) Fatalw(msg string, keysAndValues ...any) {
+	l.logw(LevelFatal, msg, keysAndValues...)
+	l.exit()
+}
+func (l *logger) Panicw(msg string, keysAndValues ...any) {
+	l.logw(LevelPanic, msg, keysAndValues...)
+	l.flush()
+	panic(msg)
+}
+func (l *logger) Tracew(msg string, keysAndValues ...any) { l.logw(LevelTrace, msg, keysAndValues...) }
+
+// LogAttrs logs msg at level with the given attributes.
+func (l *logger) LogAttrs(level CustomLevel, msg string, attrs ...slog.Attr) {
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	l.logw(level, msg, args...)
 }
-func (l *logger) Traceln(args ...any) { l.Trace(args...) }
 
 func (l *logger) With(key string, value any) Logger {
-	return &logger{l.Logger.With(key, value), l.level, l.format}
+	return l.derive(func(sl *slog.Logger) *slog.Logger { return sl.With(key, value) })
 }
 
 func (l *logger) Withs(fields Fields) Logger {
@@ -198,11 +316,66 @@ func (l *logger) Withs(fields Fields) Logger {
 	for k, v := range fields {
 		attrs = append(attrs, k, v)
 	}
-	return &logger{l.Logger.With(attrs...), l.level, l.format}
+	return l.derive(func(sl *slog.Logger) *slog.Logger { return sl.With(attrs...) })
+}
+
+// WithError attaches err as a structured "error" group, see ErrorAttr.
+func (l *logger) WithError(err error) Logger {
+	return l.derive(func(sl *slog.Logger) *slog.Logger { return sl.With(ErrorAttr(err)) })
+}
+
+// WithGroup returns a logger that nests the attributes added after it, and
+// those of each record, under name. The prefix stays at the top level.
+func (l *logger) WithGroup(name string) Logger {
+	return l.derive(func(sl *slog.Logger) *slog.Logger { return sl.WithGroup(name) })
+}
+
+// Named returns a logger whose prefix is extended with name, e.g. Named("tx")
+// on "fiberapp.postgres" logs with prefix "fiberapp.postgres.tx". Attributes
+// and groups added to l are kept.
+func (l *logger) Named(name string) Logger {
+	c := *l
+	c.prefix = joinPrefix(l.prefix, name)
+
+	sl := l.root
+	if c.prefix != "" {
+		sl = sl.With(PrefixKey, c.prefix)
+	}
+	for _, op := range l.ops {
+		sl = op(sl)
+	}
+	c.Logger = sl
+
+	return &c
 }
 
+// joinPrefix joins prefix segments with dots, ignoring empty ones.
+func joinPrefix(prefix, name string) string {
+	return strings.Trim(prefix+"."+name, ".")
+}
+
+// WithContext returns a logger that passes ctx to the handlers, e.g. so the
+// OpenTelemetry output can correlate records with the active span.
+func (l *logger) WithContext(ctx context.Context) Logger {
+	c := l.with(l.Logger)
+	c.ctx = ctx
+	return c
+}
+
+// WithSrc returns a logger that records the caller on every level.
 func (l *logger) WithSrc() Logger {
-	return l.debugSrc()
+	c := l.with(l.Logger)
+	c.sources = allLevels
+	return c
+}
+
+// WithCallerSkip returns a logger that reports the caller n frames further
+// up the stack. Adapters that forward to a Logger use it so the source points
+// at their caller instead of the adapter.
+func (l *logger) WithCallerSkip(n int) Logger {
+	c := l.with(l.Logger)
+	c.skip += n
+	return c
 }
 
 func mustParseLevel(level string) CustomLevel {
@@ -238,29 +411,57 @@ type AppLogger interface {
 	GetLogger(prefix string) Logger
 }
 
-func GlobalLogger() AppLogger {
-	return defaultLogger
-}
-
 func NewAppLogger(config *Config) AppLogger {
 	return newAppLogger(config)
 }
 
-func SetGlobalLogger(logger AppLogger) {
-	if appLogger, ok := logger.(*appLogger); ok {
-		defaultLogger = appLogger
-	}
-}
-
 type Config struct {
 	DefaultLevel string
 	BasePrefix   string
-	Format       string
+	// Format is one of "text" (default), "json", "logfmt", "ecs", "gcp" or
+	// "datadog".
+	Format string
+	// GCPProjectID is used to build the fully qualified trace name expected
+	// by Cloud Logging when Format is "gcp".
+	GCPProjectID string
+
+	// Sampling thins out repeated records with the same level and message.
+	// Nil disables sampling.
+	Sampling *SamplingConfig
+	// RateLimit caps the total number of records written per second.
+	// Nil disables rate limiting.
+	RateLimit *RateLimitConfig
+	// DropSummaryInterval is how often a summary of records dropped by
+	// sampling or rate limiting is logged. Defaults to one minute.
+	DropSummaryInterval time.Duration
+	// Async writes records from a background goroutine. Nil keeps writes
+	// synchronous.
+	Async *AsyncConfig
+	// Handler replaces the output handler selected by Format. Sampling, rate
+	// limiting and async writes still wrap it.
+	Handler slog.Handler
+	// AddSource records the caller's file and line on every level.
+	AddSource bool
+	// SourceLevels lists the levels that record the caller when AddSource is
+	// off. Defaults to debug only.
+	SourceLevels []string
+	// Theme names the colors of the text format, see RegisterTheme.
+	// Defaults to DefaultTheme. Colors are off when NO_COLOR is set or
+	// stderr is not a terminal, unless FORCE_COLOR is set.
+	Theme string
+	// Outputs receive every record that passes DefaultLevel in addition to
+	// the main output, e.g. an OpenTelemetry bridge from package otellog.
+	Outputs []slog.Handler
 }
 
 type appLogger struct {
-	logger *slog.Logger
-	cfg    Config
+	logger  *slog.Logger
+	cfg     Config
+	sources levelSet
+	theme   *Theme
+	metrics *logCounters
+	sampler *sampler
+	async   *asyncQueue
 }
 
 func newAppLogger(config *Config) *appLogger {
@@ -276,24 +477,99 @@ func newAppLogger(config *Config) *appLogger {
 		config.Format = "text"
 	}
 
-	l := createSlogLogger(mustParseLevel(config.DefaultLevel), config.Format)
+	al := &appLogger{cfg: *config, theme: themeFor(config), metrics: &logCounters{}}
+
+	switch {
+	case config.AddSource:
+		al.sources = allLevels
+	case config.SourceLevels == nil:
+		al.sources = levelSetOf(LevelDebug)
+	default:
+		for _, lvl := range config.SourceLevels {
+			al.sources |= levelSetOf(mustParseLevel(lvl))
+		}
+	}
+
+	h := config.Handler
+	if h == nil {
+		h = createSlogLogger(config, al.theme).Handler()
+	}
+
+	if len(config.Outputs) > 0 {
+		h = &fanoutHandler{main: h, outputs: config.Outputs}
+	}
+
+	// Count records as they are written, after the async queue may have
+	// dropped them.
+	h = newMetricsHandler(h, al.metrics)
 
-	return &appLogger{
-		logger: l,
-		cfg:    *config,
+	if config.Async != nil {
+		al.async = newAsyncQueue(*config.Async)
+		h = &asyncHandler{next: h, queue: al.async}
 	}
+
+	if config.Sampling != nil || config.RateLimit != nil {
+		al.sampler = newSampler(config.Sampling, config.RateLimit, h)
+		al.sampler.start(config.DropSummaryInterval)
+		h = &samplingHandler{next: h, sampler: al.sampler}
+	}
+
+	h = &captureHandler{next: h}
+
+	al.logger = slog.New(h)
+
+	if config.Theme != "" {
+		if _, ok := LookupTheme(config.Theme); !ok {
+			al.GetLogger("").Warnw("Unknown log theme, using the default theme", "theme", config.Theme)
+		}
+	}
+
+	return al
 }
 
 func (al *appLogger) GetLogger(prefix string) Logger {
-	prefix = al.cfg.BasePrefix + "." + prefix
-	prefix = strings.Trim(prefix, ".")
+	prefix = joinPrefix(al.cfg.BasePrefix, prefix)
 
 	l := al.logger
 	if prefix != "" {
-		l = l.With("prefix", prefix)
+		l = l.With(PrefixKey, prefix)
+	}
+
+	return &logger{
+		Logger:  l,
+		root:    al.logger,
+		prefix:  prefix,
+		level:   mustParseLevel(al.cfg.DefaultLevel),
+		format:  al.cfg.Format,
+		flush:   al.flush,
+		ctx:     context.Background(),
+		sources: al.sources,
+		theme:   al.theme,
+	}
+}
+
+// GetLogMetrics returns the number of records written per prefix and level,
+// and dropped by sampling, rate limiting and async overflow.
+func (al *appLogger) GetLogMetrics() LogMetrics {
+	m := LogMetrics{Records: al.metrics.snapshot()}
+
+	if al.sampler != nil {
+		m.DroppedSampling = al.sampler.totalDropped.Load()
+		m.DroppedRateLimit = al.sampler.totalLimited.Load()
+	}
+
+	if al.async != nil {
+		m.DroppedAsync = al.async.dropped.Load()
 	}
 
-	return &logger{l, mustParseLevel(al.cfg.DefaultLevel), al.cfg.Format}
+	return m
+}
+
+// flush waits until records queued by the async writer have been written.
+func (al *appLogger) flush() {
+	if al.async != nil {
+		al.async.flush()
+	}
 }
 
 func (*appLogger) ID() string {
@@ -305,58 +581,58 @@ func (al *appLogger) InitFlags() {
 }
 
 func (al *appLogger) Activate(_ ServiceContext) error {
-	al.logger = createSlogLogger(mustParseLevel(al.cfg.DefaultLevel), al.cfg.Format)
-
+	// The handler chain is built in newAppLogger so loggers handed out before
+	// activation keep writing through the same sampler.
 	return nil
 }
 
 func (al *appLogger) Stop() error {
+	if al.sampler != nil {
+		al.sampler.stop()
+	}
+
+	if al.async != nil {
+		if al.async.close() {
+			if n := al.async.dropped.Load(); n > 0 {
+				al.warn("Log records dropped by async queue overflow",
+					slog.String("reason", "async_overflow"), slog.Uint64("count", n))
+			}
+		}
+	}
+
 	return nil
 }
 
-const (
-	ansiReset          = "\033[0m"
-	ansiFaint          = "\033[2m"
-	ansiResetFaint     = "\033[22m"
-	ansiBrightRed      = "\033[91m"
-	ansiBrightGreen    = "\033[92m"
-	ansiBrightYellow   = "\033[93m"
-	ansiBrightBlue     = "\033[94m"
-	ansiBrightMagenta  = "\033[95m"
-	ansiBrightCyan     = "\033[96m"
-	ansiBrightRedFaint = "\033[91;2m"
-	ansiBackgroundRed  = "\033[41m"
-)
+// warn writes a record about the logger itself. It has no caller, which
+// would be a frame of this package.
+func (al *appLogger) warn(msg string, attrs ...slog.Attr) {
+	ctx := context.Background()
+	h := al.logger.Handler()
+	if !h.Enabled(ctx, slog.LevelWarn) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, msg, 0)
+	r.AddAttrs(attrs...)
+	_ = h.Handle(ctx, r)
+}
 
-func createSlogLogger(level CustomLevel, format string) *slog.Logger {
+func createSlogLogger(cfg *Config, theme *Theme) *slog.Logger {
 	w := os.Stderr
+	level := mustParseLevel(cfg.DefaultLevel)
 
-	if format == "json" {
+	switch cfg.Format {
+	case "json":
 		return slog.New(
 			slog.NewJSONHandler(w, &slog.HandlerOptions{
-				AddSource: false,
+				AddSource: true,
 				Level:     level.Level(),
 				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.SourceKey && len(groups) == 0 {
+						return compactSource(a)
+					}
 					if a.Key == slog.LevelKey {
-						lvl := a.Value.Any().(slog.Level)
-						switch {
-						case lvl == LevelTrace.Level():
-							a.Value = slog.StringValue("TRACE")
-						case lvl == LevelDebug.Level():
-							a.Value = slog.StringValue("DEBUG")
-						case lvl == LevelInfo.Level():
-							a.Value = slog.StringValue("INFO")
-						case lvl == LevelWarn.Level():
-							a.Value = slog.StringValue("WARN")
-						case lvl == LevelError.Level():
-							a.Value = slog.StringValue("ERROR")
-						case lvl == LevelFatal.Level():
-							a.Value = slog.StringValue("FATAL")
-						case lvl == LevelPanic.Level():
-							a.Value = slog.StringValue("PANIC")
-						default:
-							a.Value = slog.StringValue("UNKNOWN")
-						}
+						a.Value = slog.StringValue(levelLabel(a.Value.Any().(slog.Level)))
 					}
 					if a.Key == slog.TimeKey {
 						if t, ok := a.Value.Any().(time.Time); ok {
@@ -368,37 +644,41 @@ func createSlogLogger(level CustomLevel, format string) *slog.Logger {
 				},
 			}),
 		)
+	case "logfmt":
+		return slog.New(newLogfmtHandler(w, level))
+	case "ecs", "gcp", "datadog":
+		return slog.New(newVendorJSONHandler(w, level, cfg))
 	}
 
-	// Default to text format with colors
+	// Default to text format, colored by the theme
 	return slog.New(
 		tint.NewHandler(w, &tint.Options{
-			AddSource:  false,
+			AddSource:  true,
 			Level:      level.Level(),
-			NoColor:    !isatty.IsTerminal(w.Fd()),
+			NoColor:    theme == nil,
 			TimeFormat: RFC3339Milli,
 			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-				if a.Key == slog.LevelKey {
-					lvl := a.Value.Any().(slog.Level)
-					switch {
-					case lvl == LevelTrace.Level():
-						a.Value = slog.StringValue(ansiFaint + "TRACE" + ansiReset)
-					case lvl == LevelDebug.Level():
-						a.Value = slog.StringValue(ansiBrightBlue + "DEBUG" + ansiReset)
-					case lvl == LevelInfo.Level():
-						a.Value = slog.StringValue(ansiBrightGreen + "INFO" + ansiReset)
-					case lvl == LevelWarn.Level():
-						a.Value = slog.StringValue(ansiBrightYellow + "WARN" + ansiReset)
-					case lvl == LevelError.Level():
-						a.Value = slog.StringValue(ansiBrightRed + "ERROR" + ansiReset)
-					case lvl == LevelFatal.Level():
-						a.Value = slog.StringValue(ansiBrightMagenta + "FATAL" + ansiReset)
-					case lvl == LevelPanic.Level():
-						a.Value = slog.StringValue(ansiBackgroundRed + ansiBrightCyan + "PANIC" + ansiReset)
-					default:
-						a.Value = slog.StringValue("UNKNOWN")
+				if len(groups) == 0 {
+					switch a.Key {
+					case slog.SourceKey:
+						return compactSource(a)
+					case slog.TimeKey, slog.MessageKey:
+						return a
+					case slog.LevelKey:
+						lvl := a.Value.Any().(slog.Level)
+						label := levelLabel(lvl)
+						if theme != nil {
+							label = theme.Level(LevelOf(lvl)).Paint(label)
+						}
+						return slog.String(a.Key, label)
 					}
 				}
+				if len(groups) == 1 && groups[0] == ErrorKey && a.Key == "message" {
+					return tint.Attr(9, a)
+				}
+				if theme != nil {
+					return theme.paintAttr(a)
+				}
 				return a
 			},
 		}),
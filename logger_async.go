@@ -0,0 +1,194 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultAsyncBufferSize = 1024
+
+// OverflowPolicy decides what an async logger does when its queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the caller wait until the writer catches up.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest discards the oldest queued record to make room.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest discards the record being logged.
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+)
+
+// AsyncConfig enables writing records from a background goroutine so callers
+// don't wait on the output. Queued records are flushed when the logger
+// component is stopped and before Fatal exits or Panic panics.
+type AsyncConfig struct {
+	// BufferSize is the capacity of the queue. Defaults to 1024.
+	BufferSize int
+	// Overflow defaults to OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+type asyncEntry struct {
+	ctx context.Context
+	h   slog.Handler
+	r   slog.Record
+}
+
+// asyncQueue is a bounded ring buffer drained by a single writer goroutine.
+type asyncQueue struct {
+	policy OverflowPolicy
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	buf      []asyncEntry
+	head     int
+	size     int
+	busy     bool
+	closed   bool
+
+	dropped atomic.Uint64
+	done    chan struct{}
+}
+
+func newAsyncQueue(cfg AsyncConfig) *asyncQueue {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultAsyncBufferSize
+	}
+
+	if cfg.Overflow == "" {
+		cfg.Overflow = OverflowBlock
+	}
+
+	q := &asyncQueue{
+		policy: cfg.Overflow,
+		buf:    make([]asyncEntry, cfg.BufferSize),
+		done:   make(chan struct{}),
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	q.idle = sync.NewCond(&q.mu)
+
+	go q.run()
+
+	return q
+}
+
+func (q *asyncQueue) push(e asyncEntry) error {
+	q.mu.Lock()
+
+	if q.closed {
+		q.mu.Unlock()
+		return e.h.Handle(e.ctx, e.r)
+	}
+
+	for q.size == len(q.buf) {
+		switch q.policy {
+		case OverflowDropNewest:
+			q.mu.Unlock()
+			q.dropped.Add(1)
+			return nil
+		case OverflowDropOldest:
+			q.buf[q.head] = asyncEntry{}
+			q.head = (q.head + 1) % len(q.buf)
+			q.size--
+			q.dropped.Add(1)
+		default:
+			q.notFull.Wait()
+			if q.closed {
+				q.mu.Unlock()
+				return e.h.Handle(e.ctx, e.r)
+			}
+		}
+	}
+
+	q.buf[(q.head+q.size)%len(q.buf)] = e
+	q.size++
+	q.notEmpty.Signal()
+	q.mu.Unlock()
+
+	return nil
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for {
+		q.mu.Lock()
+		for q.size == 0 && !q.closed {
+			q.busy = false
+			q.idle.Broadcast()
+			q.notEmpty.Wait()
+		}
+
+		if q.size == 0 {
+			q.busy = false
+			q.idle.Broadcast()
+			q.mu.Unlock()
+			return
+		}
+
+		e := q.buf[q.head]
+		q.buf[q.head] = asyncEntry{}
+		q.head = (q.head + 1) % len(q.buf)
+		q.size--
+		q.busy = true
+		q.notFull.Signal()
+		q.mu.Unlock()
+
+		_ = e.h.Handle(e.ctx, e.r)
+	}
+}
+
+// flush blocks until every queued record has been written.
+func (q *asyncQueue) flush() {
+	q.mu.Lock()
+	for q.size > 0 || q.busy {
+		q.idle.Wait()
+	}
+	q.mu.Unlock()
+}
+
+// close drains the queue and stops the writer. Records logged afterwards are
+// written synchronously. It reports false when the queue was already closed.
+func (q *asyncQueue) close() bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+
+	return true
+}
+
+// asyncHandler hands records to the queue instead of writing them directly.
+type asyncHandler struct {
+	next  slog.Handler
+	queue *asyncQueue
+}
+
+func (h *asyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *asyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.queue.push(asyncEntry{ctx: context.WithoutCancel(ctx), h: h.next, r: r.Clone()})
+}
+
+func (h *asyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &asyncHandler{next: h.next.WithAttrs(attrs), queue: h.queue}
+}
+
+func (h *asyncHandler) WithGroup(name string) slog.Handler {
+	return &asyncHandler{next: h.next.WithGroup(name), queue: h.queue}
+}
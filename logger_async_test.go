@@ -0,0 +1,172 @@
+package sctx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// push queues a record with message msg for h.
+func push(q *asyncQueue, h slog.Handler, msg string) {
+	_ = q.push(asyncEntry{ctx: context.Background(), h: h, r: slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)})
+}
+
+// stalledQueue returns a queue whose writer is blocked in h on a record
+// "first", so later records stay queued until h is released.
+func stalledQueue(t *testing.T, cfg AsyncConfig) (*asyncQueue, *gateHandler) {
+	t.Helper()
+
+	h := newGateHandler()
+	q := newAsyncQueue(cfg)
+	t.Cleanup(func() {
+		select {
+		case <-h.release:
+		default:
+			close(h.release)
+		}
+		q.close()
+	})
+
+	push(q, h, "first")
+	<-h.started
+
+	return q, h
+}
+
+// returnsWithin reports whether fn returns within d.
+func returnsWithin(d time.Duration, fn func()) (chan struct{}, bool) {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return done, true
+	case <-time.After(d):
+		return done, false
+	}
+}
+
+func TestAsyncQueueOverflow(t *testing.T) {
+	tests := []struct {
+		policy  OverflowPolicy
+		want    []string
+		dropped uint64
+	}{
+		{OverflowDropNewest, []string{"first", "1", "2"}, 4},
+		{OverflowDropOldest, []string{"first", "5", "6"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			q, h := stalledQueue(t, AsyncConfig{BufferSize: 2, Overflow: tt.policy})
+
+			for i := 1; i <= 6; i++ {
+				push(q, h, strconv.Itoa(i))
+			}
+
+			close(h.release)
+			q.flush()
+
+			if got := h.messages(); !slices.Equal(got, tt.want) {
+				t.Errorf("written %q, want %q", got, tt.want)
+			}
+			if got := q.dropped.Load(); got != tt.dropped {
+				t.Errorf("dropped %d, want %d", got, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestAsyncQueueBlock(t *testing.T) {
+	q, h := stalledQueue(t, AsyncConfig{BufferSize: 1})
+
+	push(q, h, "1")
+
+	done, ok := returnsWithin(50*time.Millisecond, func() { push(q, h, "2") })
+	if ok {
+		t.Fatal("push returned while the queue was full")
+	}
+
+	close(h.release)
+	<-done
+	q.flush()
+
+	if got, want := h.messages(), []string{"first", "1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("written %q, want %q", got, want)
+	}
+	if got := q.dropped.Load(); got != 0 {
+		t.Errorf("dropped %d, want 0", got)
+	}
+}
+
+func TestAsyncQueueFlush(t *testing.T) {
+	q, h := stalledQueue(t, AsyncConfig{BufferSize: 4})
+
+	push(q, h, "1")
+	push(q, h, "2")
+
+	done, ok := returnsWithin(50*time.Millisecond, q.flush)
+	if ok {
+		t.Fatal("flush returned before the queued records were written")
+	}
+
+	close(h.release)
+	<-done
+
+	if got, want := h.messages(), []string{"first", "1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("written %q after flush, want %q", got, want)
+	}
+}
+
+func TestAsyncQueueClose(t *testing.T) {
+	q, h := stalledQueue(t, AsyncConfig{BufferSize: 4})
+
+	push(q, h, "1")
+	close(h.release)
+
+	if !q.close() {
+		t.Fatal("first close reported the queue as already closed")
+	}
+	if got, want := h.messages(), []string{"first", "1"}; !slices.Equal(got, want) {
+		t.Errorf("written %q after close, want %q", got, want)
+	}
+
+	// Records logged after close are written by the caller.
+	push(q, h, "2")
+	if got, want := h.messages(), []string{"first", "1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("written %q after a push to a closed queue, want %q", got, want)
+	}
+
+	if q.close() {
+		t.Error("second close reported the queue as open")
+	}
+}
+
+func TestStopOverflowWarningHasNoSource(t *testing.T) {
+	var buf bytes.Buffer
+	al := NewAppLogger(&Config{
+		Handler: slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}),
+		Async:   &AsyncConfig{BufferSize: 1, Overflow: OverflowDropNewest},
+	}).(*appLogger)
+	al.async.dropped.Store(3)
+
+	if err := al.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"Log records dropped by async queue overflow"`) || !strings.Contains(out, `"count":3`) {
+		t.Fatalf("output = %s, want the overflow warning", out)
+	}
+	if strings.Contains(out, `"source"`) {
+		t.Errorf("overflow warning has a source: %s", out)
+	}
+}
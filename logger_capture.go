@@ -0,0 +1,212 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const defaultCaptureMaxRecords = 1000
+
+// CaptureConfig configures a request-scoped log capture.
+type CaptureConfig struct {
+	// MaxRecords bounds the number of buffered records. The oldest ones are
+	// dropped first. Defaults to 1000.
+	MaxRecords int
+	// FlushLevel is the level at which a record flushes the buffer as soon
+	// as it is logged. Defaults to "error".
+	FlushLevel string
+}
+
+type captureKey struct{}
+
+// captureFlushKey marks the context of records replayed by Capture.Flush so
+// sampling doesn't thin them out again.
+type captureFlushKey struct{}
+
+type captureEntry struct {
+	ctx context.Context
+	h   slog.Handler
+	r   slog.Record
+}
+
+type captureState int
+
+const (
+	captureBuffering captureState = iota
+	captureFlushed
+	captureDiscarded
+)
+
+// Capture buffers the records logged with its context that are below the
+// logger's level, e.g. debug lines of a request served at info level, so
+// they can be written if the request fails and thrown away otherwise.
+type Capture struct {
+	max        int
+	flushLevel slog.Level
+
+	mu      sync.Mutex
+	state   captureState
+	entries []captureEntry
+	dropped int
+}
+
+// NewCaptureContext returns a context that captures the records logged with
+// it through Logger.WithContext or the slog *Context methods, and the
+// Capture to end it with.
+//
+//	ctx, capture := sctx.NewCaptureContext(c.UserContext(), sctx.CaptureConfig{})
+//	err := next(ctx)
+//	capture.End(err)
+//
+// Once the buffer is flushed, later records below the level are written
+// directly until the context is done with.
+func NewCaptureContext(ctx context.Context, cfg CaptureConfig) (context.Context, *Capture) {
+	if cfg.MaxRecords <= 0 {
+		cfg.MaxRecords = defaultCaptureMaxRecords
+	}
+
+	if cfg.FlushLevel == "" {
+		cfg.FlushLevel = "error"
+	}
+
+	c := &Capture{
+		max:        cfg.MaxRecords,
+		flushLevel: mustParseLevel(cfg.FlushLevel).Level(),
+	}
+
+	return context.WithValue(ctx, captureKey{}, c), c
+}
+
+func captureFrom(ctx context.Context) *Capture {
+	if ctx == nil {
+		return nil
+	}
+
+	c, _ := ctx.Value(captureKey{}).(*Capture)
+	return c
+}
+
+func isCaptureFlush(ctx context.Context) bool {
+	return ctx != nil && ctx.Value(captureFlushKey{}) != nil
+}
+
+// End flushes the buffer when err is non-nil and discards it otherwise.
+func (c *Capture) End(err error) {
+	if err != nil {
+		c.Flush()
+		return
+	}
+
+	c.Discard()
+}
+
+// Flush writes the buffered records in the order they were logged. Records
+// logged with the context afterwards are written directly.
+func (c *Capture) Flush() {
+	c.mu.Lock()
+	if c.state != captureBuffering {
+		c.mu.Unlock()
+		return
+	}
+	entries, dropped := c.entries, c.dropped
+	c.state, c.entries, c.dropped = captureFlushed, nil, 0
+	c.mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	if dropped > 0 {
+		first := entries[0]
+		r := slog.NewRecord(time.Now(), slog.LevelWarn, "Log records dropped from capture buffer", 0)
+		r.AddAttrs(slog.String("reason", "capture_overflow"), slog.Int("count", dropped))
+		_ = first.h.Handle(context.WithValue(first.ctx, captureFlushKey{}, true), r)
+	}
+
+	for _, e := range entries {
+		_ = e.h.Handle(context.WithValue(e.ctx, captureFlushKey{}, true), e.r)
+	}
+}
+
+// Discard drops the buffered records and stops capturing.
+func (c *Capture) Discard() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == captureBuffering {
+		c.state, c.entries, c.dropped = captureDiscarded, nil, 0
+	}
+}
+
+func (c *Capture) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state != captureDiscarded
+}
+
+// buffer keeps r for h, reporting false when the capture was already flushed
+// and r should be written directly.
+func (c *Capture) buffer(ctx context.Context, h slog.Handler, r slog.Record) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case captureFlushed:
+		return false
+	case captureDiscarded:
+		return true
+	}
+
+	if len(c.entries) >= c.max {
+		c.entries[0] = captureEntry{}
+		c.entries = c.entries[1:]
+		c.dropped++
+	}
+
+	c.entries = append(c.entries, captureEntry{ctx: ctx, h: h, r: r.Clone()})
+
+	return true
+}
+
+// captureHandler enables every level for contexts carrying a Capture and
+// buffers the records the next handler would have filtered out.
+type captureHandler struct {
+	next slog.Handler
+}
+
+func (h *captureHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next.Enabled(ctx, level) {
+		return true
+	}
+
+	c := captureFrom(ctx)
+	return c != nil && c.enabled()
+}
+
+func (h *captureHandler) Handle(ctx context.Context, r slog.Record) error {
+	c := captureFrom(ctx)
+	if c == nil {
+		return h.next.Handle(ctx, r)
+	}
+
+	if r.Level >= c.flushLevel {
+		c.Flush()
+	}
+
+	if h.next.Enabled(ctx, r.Level) || !c.buffer(ctx, h.next, r) {
+		return h.next.Handle(ctx, r)
+	}
+
+	return nil
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &captureHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	return &captureHandler{next: h.next.WithGroup(name)}
+}
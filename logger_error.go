@@ -0,0 +1,55 @@
+package sctx
+
+import (
+	"log/slog"
+
+	"github.com/phathdt/service-context/internal/errinfo"
+)
+
+// ErrorKey is the attribute key used by WithError and ErrorAttr.
+const ErrorKey = "error"
+
+// ErrorAttr returns an attribute that logs err as a group with its message,
+// cause chain and stack frames. Errors implementing slog.LogValuer, such as
+// core.DefaultError, decide their own shape.
+func ErrorAttr(err error) slog.Attr {
+	return slog.Any(ErrorKey, errorValue{err: err})
+}
+
+type errorValue struct {
+	err error
+}
+
+func (v errorValue) LogValue() slog.Value {
+	if v.err == nil {
+		return slog.StringValue("<nil>")
+	}
+
+	if lv, ok := v.err.(slog.LogValuer); ok {
+		return lv.LogValue()
+	}
+
+	attrs := []slog.Attr{slog.String("message", v.err.Error())}
+
+	if causes := ErrorCauses(v.err); len(causes) > 0 {
+		attrs = append(attrs, slog.Any("causes", causes))
+	}
+
+	if frames := StackFrames(v.err); len(frames) > 0 {
+		attrs = append(attrs, slog.Any("stack", frames))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// ErrorCauses returns the messages of the errors wrapped by err, outermost
+// first, skipping wrappers that don't change the message.
+func ErrorCauses(err error) []string {
+	return errinfo.Causes(err)
+}
+
+// StackFrames formats the stack trace recorded by github.com/pkg/errors
+// closest to the origin of err as "function file:line" entries.
+func StackFrames(err error) []string {
+	return errinfo.StackFrames(err)
+}
@@ -0,0 +1,128 @@
+package sctx
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultExitTimeout = 5 * time.Second
+
+// ExitHook runs before Fatal exits the process. The context is canceled when
+// the exit timeout elapses.
+type ExitHook func(ctx context.Context)
+
+var exitState = struct {
+	mu      sync.Mutex
+	hooks   map[int]ExitHook
+	next    int
+	exit    func(code int)
+	timeout time.Duration
+}{
+	hooks:   make(map[int]ExitHook),
+	exit:    os.Exit,
+	timeout: defaultExitTimeout,
+}
+
+// RegisterExitHook adds a hook that Fatal runs before exiting. Hooks run in
+// reverse registration order, like deferred calls. The returned function
+// removes the hook.
+func RegisterExitHook(hook ExitHook) (unregister func()) {
+	exitState.mu.Lock()
+	defer exitState.mu.Unlock()
+
+	id := exitState.next
+	exitState.next++
+	exitState.hooks[id] = hook
+
+	return func() {
+		exitState.mu.Lock()
+		defer exitState.mu.Unlock()
+
+		delete(exitState.hooks, id)
+	}
+}
+
+// SetExitFunc replaces the function Fatal calls after the exit hooks, os.Exit
+// by default. Passing nil restores os.Exit. The returned function restores the
+// previous exit function, e.g. in t.Cleanup. If the exit function returns,
+// Fatal returns to its caller.
+func SetExitFunc(fn func(code int)) (restore func()) {
+	if fn == nil {
+		fn = os.Exit
+	}
+
+	exitState.mu.Lock()
+	defer exitState.mu.Unlock()
+
+	prev := exitState.exit
+	exitState.exit = fn
+
+	return func() {
+		exitState.mu.Lock()
+		defer exitState.mu.Unlock()
+
+		exitState.exit = prev
+	}
+}
+
+// SetExitTimeout bounds how long Fatal waits for all exit hooks together.
+// Defaults to five seconds.
+func SetExitTimeout(d time.Duration) {
+	exitState.mu.Lock()
+	defer exitState.mu.Unlock()
+
+	exitState.timeout = d
+}
+
+func exitFunc() func(code int) {
+	exitState.mu.Lock()
+	defer exitState.mu.Unlock()
+
+	return exitState.exit
+}
+
+// runExitHooks runs the hooks newest first and returns once they finish or
+// the exit timeout elapses, whichever comes first.
+func runExitHooks() {
+	exitState.mu.Lock()
+	hooks := make([]ExitHook, 0, len(exitState.hooks))
+	for i := exitState.next - 1; i >= 0 && len(hooks) < len(exitState.hooks); i-- {
+		if h, ok := exitState.hooks[i]; ok {
+			hooks = append(hooks, h)
+		}
+	}
+	timeout := exitState.timeout
+	exitState.mu.Unlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, h := range hooks {
+			if ctx.Err() != nil {
+				return
+			}
+			runExitHook(ctx, h)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// runExitHook keeps a panicking hook from preventing the exit.
+func runExitHook(ctx context.Context, h ExitHook) {
+	defer func() { _ = recover() }()
+
+	h(ctx)
+}
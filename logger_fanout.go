@@ -0,0 +1,49 @@
+package sctx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// fanoutHandler writes every record to the main handler and to each extra
+// output that is enabled for the record's level. Level filtering for the whole
+// chain is decided by the main handler.
+type fanoutHandler struct {
+	main    slog.Handler
+	outputs []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.main.Enabled(ctx, level)
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.main.Handle(ctx, r)
+
+	for _, o := range h.outputs {
+		if o.Enabled(ctx, r.Level) {
+			err = errors.Join(err, o.Handle(ctx, r.Clone()))
+		}
+	}
+
+	return err
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	outputs := make([]slog.Handler, len(h.outputs))
+	for i, o := range h.outputs {
+		outputs[i] = o.WithAttrs(attrs)
+	}
+
+	return &fanoutHandler{main: h.main.WithAttrs(attrs), outputs: outputs}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	outputs := make([]slog.Handler, len(h.outputs))
+	for i, o := range h.outputs {
+		outputs[i] = o.WithGroup(name)
+	}
+
+	return &fanoutHandler{main: h.main.WithGroup(name), outputs: outputs}
+}
@@ -0,0 +1,314 @@
+package sctx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys that vendor formats rename to the fields their ingestion
+// pipelines expect.
+const (
+	PrefixKey  = "prefix"
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+const ecsVersion = "8.11.0"
+
+// levelLabel returns the upper case name used for a level in JSON and logfmt
+// output.
+func levelLabel(lvl slog.Level) string {
+	switch lvl {
+	case LevelTrace.Level():
+		return "TRACE"
+	case LevelDebug.Level():
+		return "DEBUG"
+	case LevelInfo.Level():
+		return "INFO"
+	case LevelWarn.Level():
+		return "WARN"
+	case LevelError.Level():
+		return "ERROR"
+	case LevelFatal.Level():
+		return "FATAL"
+	case LevelPanic.Level():
+		return "PANIC"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func newLogfmtHandler(w io.Writer, level CustomLevel) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level.Level(),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+
+			switch a.Key {
+			case slog.SourceKey:
+				return compactSource(a)
+			case slog.LevelKey:
+				a.Value = slog.StringValue(levelLabel(a.Value.Any().(slog.Level)))
+			case slog.TimeKey:
+				a.Value = slog.StringValue(a.Value.Time().Format(RFC3339Milli))
+			}
+
+			return a
+		},
+	})
+}
+
+// vendorFormat describes how a log backend names the standard fields.
+type vendorFormat struct {
+	timeKey    string
+	levelKey   string
+	messageKey string
+	loggerKey  string
+	traceKey   string
+	spanKey    string
+	severity   func(slog.Level) string
+	traceValue func(string) string
+	spanValue  func(string) string
+	source     func(*slog.Source) slog.Attr
+	static     []slog.Attr
+}
+
+func vendorFormatFor(cfg *Config) vendorFormat {
+	switch cfg.Format {
+	case "ecs":
+		return vendorFormat{
+			timeKey:    "@timestamp",
+			levelKey:   "log.level",
+			messageKey: "message",
+			loggerKey:  "log.logger",
+			traceKey:   "trace.id",
+			spanKey:    "span.id",
+			severity:   levelName,
+			source: func(src *slog.Source) slog.Attr {
+				return slog.Group("log.origin",
+					slog.String("file.name", src.File),
+					slog.Int("file.line", src.Line),
+					slog.String("function", src.Function),
+				)
+			},
+			static: []slog.Attr{slog.String("ecs.version", ecsVersion)},
+		}
+	case "gcp":
+		return vendorFormat{
+			timeKey:    "timestamp",
+			levelKey:   "severity",
+			messageKey: "message",
+			loggerKey:  "logger",
+			traceKey:   "logging.googleapis.com/trace",
+			spanKey:    "logging.googleapis.com/spanId",
+			severity:   gcpSeverity,
+			source: func(src *slog.Source) slog.Attr {
+				return slog.Any("logging.googleapis.com/sourceLocation", src)
+			},
+			traceValue: func(id string) string {
+				if cfg.GCPProjectID == "" {
+					return id
+				}
+				return "projects/" + cfg.GCPProjectID + "/traces/" + id
+			},
+		}
+	default:
+		return vendorFormat{
+			timeKey:    "timestamp",
+			levelKey:   "status",
+			messageKey: "message",
+			loggerKey:  "logger.name",
+			traceKey:   "dd.trace_id",
+			spanKey:    "dd.span_id",
+			severity:   levelName,
+			traceValue: datadogID,
+			spanValue:  datadogID,
+			// "source" is reserved by Datadog for the integration name.
+			source: func(src *slog.Source) slog.Attr {
+				return slog.String("logger.method_name", src.Function+" "+sourceString(src))
+			},
+		}
+	}
+}
+
+// newVendorJSONHandler writes JSON shaped for ECS, GCP Cloud Logging or
+// Datadog. Top level prefix, trace_id and span_id attributes are renamed to
+// the vendor's logger and correlation fields, and filled from the span in
+// the record's context when absent.
+func newVendorJSONHandler(w io.Writer, level CustomLevel, cfg *Config) slog.Handler {
+	f := vendorFormatFor(cfg)
+
+	rename := func(a slog.Attr, key string, conv func(string) string) slog.Attr {
+		a.Key = key
+		if conv != nil {
+			a.Value = slog.StringValue(conv(a.Value.String()))
+		}
+		return a
+	}
+
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level.Level(),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.String(f.timeKey, a.Value.Time().UTC().Format(time.RFC3339Nano))
+			case slog.LevelKey:
+				return slog.String(f.levelKey, f.severity(a.Value.Any().(slog.Level)))
+			case slog.MessageKey:
+				return rename(a, f.messageKey, nil)
+			case slog.SourceKey:
+				src, ok := a.Value.Any().(*slog.Source)
+				if !ok || src.File == "" {
+					return slog.Attr{}
+				}
+				if f.source != nil {
+					return f.source(src)
+				}
+				return a
+			case PrefixKey:
+				return rename(a, f.loggerKey, nil)
+			case TraceIDKey:
+				return rename(a, f.traceKey, f.traceValue)
+			case SpanIDKey:
+				return rename(a, f.spanKey, f.spanValue)
+			}
+
+			return a
+		},
+	})
+
+	var root slog.Handler = h
+	if len(f.static) > 0 {
+		root = h.WithAttrs(f.static)
+	}
+
+	return &spanContextHandler{root: root, next: root}
+}
+
+// spanContextHandler adds the trace_id and span_id of the span in the
+// record's context, unless the record or logger already carries a trace_id,
+// so vendor formats correlate records logged with Logger.WithContext.
+type spanContextHandler struct {
+	// root is next before any WithGroup, the ids are added there so they
+	// stay top level attributes.
+	root     slog.Handler
+	next     slog.Handler
+	ops      []func(slog.Handler) slog.Handler
+	grouped  bool
+	hasTrace bool
+}
+
+func (h *spanContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *spanContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	sc := trace.SpanContextFromContext(ctx)
+	if h.hasTrace || !sc.IsValid() || recordHasTrace(r) {
+		return h.next.Handle(ctx, r)
+	}
+
+	ids := []slog.Attr{
+		slog.String(TraceIDKey, sc.TraceID().String()),
+		slog.String(SpanIDKey, sc.SpanID().String()),
+	}
+
+	if !h.grouped {
+		r = r.Clone()
+		r.AddAttrs(ids...)
+		return h.next.Handle(ctx, r)
+	}
+
+	// Records of a grouped logger nest their attributes, so rebuild the
+	// handler with the ids ahead of the groups.
+	next := h.root.WithAttrs(ids)
+	for _, op := range h.ops {
+		next = op(next)
+	}
+
+	return next.Handle(ctx, r)
+}
+
+func (h *spanContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := h.derive(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == TraceIDKey {
+				c.hasTrace = true
+			}
+		}
+	}
+	return c
+}
+
+func (h *spanContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	c := h.derive(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+	c.grouped = true
+	return c
+}
+
+func (h *spanContextHandler) derive(op func(slog.Handler) slog.Handler) *spanContextHandler {
+	c := *h
+	c.next = op(h.next)
+	c.ops = append(h.ops[:len(h.ops):len(h.ops)], op)
+	return &c
+}
+
+func recordHasTrace(r slog.Record) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = a.Key == TraceIDKey
+		return !found
+	})
+	return found
+}
+
+// gcpSeverity maps levels to Cloud Logging LogSeverity names.
+func gcpSeverity(lvl slog.Level) string {
+	switch {
+	case lvl < LevelInfo.Level():
+		return "DEBUG"
+	case lvl < LevelWarn.Level():
+		return "INFO"
+	case lvl < LevelError.Level():
+		return "WARNING"
+	case lvl < LevelFatal.Level():
+		return "ERROR"
+	case lvl < LevelPanic.Level():
+		return "CRITICAL"
+	default:
+		return "ALERT"
+	}
+}
+
+// datadogID converts a hex encoded W3C trace or span ID into the unsigned
+// 64-bit decimal form Datadog correlates on. Other values pass through.
+func datadogID(id string) string {
+	if len(id) != 16 && len(id) != 32 {
+		return id
+	}
+
+	n, err := strconv.ParseUint(id[len(id)-16:], 16, 64)
+	if err != nil {
+		return id
+	}
+
+	return strconv.FormatUint(n, 10)
+}
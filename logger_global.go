@@ -0,0 +1,211 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// globalSlot wraps the current global AppLogger. A new slot is stored on every
+// swap so proxies can tell by pointer identity that their cache is stale.
+type globalSlot struct {
+	app AppLogger
+}
+
+var globalApp atomic.Pointer[globalSlot]
+
+func init() {
+	globalApp.Store(&globalSlot{app: defaultLogger})
+}
+
+// GlobalLogger returns an AppLogger that always delegates to the logger set
+// with SetGlobalLogger. Loggers obtained from it follow later swaps.
+func GlobalLogger() AppLogger {
+	return globalAppLogger{}
+}
+
+// SetGlobalLogger replaces the global logger with any AppLogger
+// implementation. It is safe to call concurrently with logging.
+func SetGlobalLogger(logger AppLogger) {
+	SwapGlobalLogger(logger)
+}
+
+// SwapGlobalLogger replaces the global logger and returns the previous one,
+// e.g. to restore it after a test. Nil and the GlobalLogger proxy itself are
+// ignored.
+func SwapGlobalLogger(logger AppLogger) AppLogger {
+	if _, ok := logger.(globalAppLogger); ok || logger == nil {
+		return globalApp.Load().app
+	}
+
+	return globalApp.Swap(&globalSlot{app: logger}).app
+}
+
+// globalAppLogger is the proxy returned by GlobalLogger. It is also the logger
+// component registered in every ServiceContext, forwarding Activate and Stop
+// to the current global logger when that logger is a Component.
+type globalAppLogger struct{}
+
+func (globalAppLogger) GetLogger(prefix string) Logger {
+	return &globalLogger{prefix: prefix}
+}
+
+func (globalAppLogger) ID() string {
+	return "logger"
+}
+
+func (globalAppLogger) Activate(sc ServiceContext) error {
+	if c, ok := globalApp.Load().app.(Component); ok {
+		return c.Activate(sc)
+	}
+
+	return nil
+}
+
+func (globalAppLogger) Stop() error {
+	if c, ok := globalApp.Load().app.(Component); ok {
+		return c.Stop()
+	}
+
+	return nil
+}
+
+// GetLogMetrics returns the metrics of the current global logger, or empty
+// metrics when it doesn't count records.
+func (globalAppLogger) GetLogMetrics() LogMetrics {
+	if p, ok := globalApp.Load().app.(LogMetricsProvider); ok {
+		return p.GetLogMetrics()
+	}
+
+	return LogMetrics{}
+}
+
+type resolvedLogger struct {
+	slot   *globalSlot
+	logger Logger
+}
+
+// globalLogger resolves its prefix and With* calls against the current global
+// AppLogger, caching the result until the global logger is swapped.
+type globalLogger struct {
+	prefix string
+	ops    []func(Logger) Logger
+	cache  atomic.Pointer[resolvedLogger]
+}
+
+func (g *globalLogger) resolve() Logger {
+	slot := globalApp.Load()
+	if c := g.cache.Load(); c != nil && c.slot == slot {
+		return c.logger
+	}
+
+	l := slot.app.GetLogger(g.prefix)
+	for _, op := range g.ops {
+		l = op(l)
+	}
+
+	// Account for the proxy frame when the logger captures the caller.
+	l = l.WithCallerSkip(1)
+
+	g.cache.Store(&resolvedLogger{slot: slot, logger: l})
+
+	return l
+}
+
+func (g *globalLogger) derive(op func(Logger) Logger) Logger {
+	ops := make([]func(Logger) Logger, len(g.ops), len(g.ops)+1)
+	copy(ops, g.ops)
+
+	return &globalLogger{prefix: g.prefix, ops: append(ops, op)}
+}
+
+func (g *globalLogger) Debug(args ...any) { g.resolve().Debug(args...) }
+func (g *globalLogger) Info(args ...any)  { g.resolve().Info(args...) }
+func (g *globalLogger) Warn(args ...any)  { g.resolve().Warn(args...) }
+func (g *globalLogger) Error(args ...any) { g.resolve().Error(args...) }
+func (g *globalLogger) Fatal(args ...any) { g.resolve().Fatal(args...) }
+func (g *globalLogger) Panic(args ...any) { g.resolve().Panic(args...) }
+func (g *globalLogger) Trace(args ...any) { g.resolve().Trace(args...) }
+
+func (g *globalLogger) Debugf(format string, args ...any) { g.resolve().Debugf(format, args...) }
+func (g *globalLogger) Infof(format string, args ...any)  { g.resolve().Infof(format, args...) }
+func (g *globalLogger) Warnf(format string, args ...any)  { g.resolve().Warnf(format, args...) }
+func (g *globalLogger) Errorf(format string, args ...any) { g.resolve().Errorf(format, args...) }
+func (g *globalLogger) Fatalf(format string, args ...any) { g.resolve().Fatalf(format, args...) }
+func (g *globalLogger) Panicf(format string, args ...any) { g.resolve().Panicf(format, args...) }
+func (g *globalLogger) Tracef(format string, args ...any) { g.resolve().Tracef(format, args...) }
+
+func (g *globalLogger) Debugln(args ...any) { g.resolve().Debugln(args...) }
+func (g *globalLogger) Infoln(args ...any)  { g.resolve().Infoln(args...) }
+func (g *globalLogger) Warnln(args ...any)  { g.resolve().Warnln(args...) }
+func (g *globalLogger) Errorln(args ...any) { g.resolve().Errorln(args...) }
+func (g *globalLogger) Fatalln(args ...any) { g.resolve().Fatalln(args...) }
+func (g *globalLogger) Panicln(args ...any) { g.resolve().Panicln(args...) }
+func (g *globalLogger) Traceln(args ...any) { g.resolve().Traceln(args...) }
+
+func (g *globalLogger) Debugw(msg string, kv ...any) { g.resolve().Debugw(msg, kv...) }
+func (g *globalLogger) Infow(msg string, kv ...any)  { g.resolve().Infow(msg, kv...) }
+func (g *globalLogger) Warnw(msg string, kv ...any)  { g.resolve().Warnw(msg, kv...) }
+func (g *globalLogger) Errorw(msg string, kv ...any) { g.resolve().Errorw(msg, kv...) }
+func (g *globalLogger) Fatalw(msg string, kv ...any) { g.resolve().Fatalw(msg, kv...) }
+func (g *globalLogger) Panicw(msg string, kv ...any) { g.resolve().Panicw(msg, kv...) }
+func (g *globalLogger) Tracew(msg string, kv ...any) { g.resolve().Tracew(msg, kv...) }
+
+func (g *globalLogger) LogAttrs(level CustomLevel, msg string, attrs ...slog.Attr) {
+	g.resolve().LogAttrs(level, msg, attrs...)
+}
+
+func (g *globalLogger) With(key string, value any) Logger {
+	return g.derive(func(l Logger) Logger { return l.With(key, value) })
+}
+
+func (g *globalLogger) Withs(fields Fields) Logger {
+	return g.derive(func(l Logger) Logger { return l.Withs(fields) })
+}
+
+func (g *globalLogger) WithError(err error) Logger {
+	return g.derive(func(l Logger) Logger { return l.WithError(err) })
+}
+
+func (g *globalLogger) WithContext(ctx context.Context) Logger {
+	return g.derive(func(l Logger) Logger { return l.WithContext(ctx) })
+}
+
+func (g *globalLogger) WithSrc() Logger {
+	return g.derive(func(l Logger) Logger { return l.WithSrc() })
+}
+
+func (g *globalLogger) WithCallerSkip(n int) Logger {
+	return g.derive(func(l Logger) Logger { return l.WithCallerSkip(n) })
+}
+
+func (g *globalLogger) WithGroup(name string) Logger {
+	return g.derive(func(l Logger) Logger { return l.WithGroup(name) })
+}
+
+func (g *globalLogger) Named(name string) Logger {
+	return g.derive(func(l Logger) Logger { return l.Named(name) })
+}
+
+func (g *globalLogger) GetLevel() string  { return g.resolve().GetLevel() }
+func (g *globalLogger) GetFormat() string { return g.resolve().GetFormat() }
+
+func (g *globalLogger) RecordsSource(level CustomLevel) bool {
+	if s, ok := g.resolve().(SourceRecorder); ok {
+		return s.RecordsSource(level)
+	}
+
+	return true
+}
+
+func (g *globalLogger) GetTheme() *Theme {
+	if t, ok := g.resolve().(Themed); ok {
+		return t.GetTheme()
+	}
+
+	return nil
+}
+
+// GetSLogger returns the slog.Logger of the current global logger. Unlike the
+// proxy itself it does not follow later swaps.
+func (g *globalLogger) GetSLogger() *slog.Logger { return g.resolve().GetSLogger() }
@@ -0,0 +1,114 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// LogMetrics is a snapshot of the records counted by an AppLogger since it
+// was created.
+type LogMetrics struct {
+	// Records counts the records written, by prefix and level. Records
+	// logged without a prefix are counted under "".
+	Records map[string]map[CustomLevel]uint64
+	// DroppedSampling, DroppedRateLimit and DroppedAsync count the records
+	// dropped by sampling, rate limiting and async queue overflow.
+	DroppedSampling  uint64
+	DroppedRateLimit uint64
+	DroppedAsync     uint64
+}
+
+// Count returns the number of records written with prefix at level.
+func (m LogMetrics) Count(prefix string, level CustomLevel) uint64 {
+	return m.Records[prefix][level]
+}
+
+// LogMetricsProvider is implemented by AppLoggers that count their records,
+// including the one returned by GlobalLogger.
+type LogMetricsProvider interface {
+	GetLogMetrics() LogMetrics
+}
+
+type levelCounters [LevelPanic - LevelTrace + 1]atomic.Uint64
+
+// logCounters holds the record counters of one AppLogger.
+type logCounters struct {
+	prefixes sync.Map // prefix -> *levelCounters
+}
+
+func (c *logCounters) forPrefix(prefix string) *levelCounters {
+	if lc, ok := c.prefixes.Load(prefix); ok {
+		return lc.(*levelCounters)
+	}
+
+	lc, _ := c.prefixes.LoadOrStore(prefix, &levelCounters{})
+	return lc.(*levelCounters)
+}
+
+func (c *logCounters) snapshot() map[string]map[CustomLevel]uint64 {
+	records := make(map[string]map[CustomLevel]uint64)
+
+	c.prefixes.Range(func(k, v any) bool {
+		lc := v.(*levelCounters)
+		levels := make(map[CustomLevel]uint64)
+		for i := range lc {
+			if n := lc[i].Load(); n > 0 {
+				levels[LevelTrace+CustomLevel(i)] = n
+			}
+		}
+		if len(levels) > 0 {
+			records[k.(string)] = levels
+		}
+		return true
+	})
+
+	return records
+}
+
+// metricsHandler counts the records that reach it under the prefix attribute
+// added to the handler by GetLogger or Named.
+type metricsHandler struct {
+	next     slog.Handler
+	counters *logCounters
+	levels   *levelCounters
+	grouped  bool
+}
+
+func newMetricsHandler(next slog.Handler, counters *logCounters) *metricsHandler {
+	return &metricsHandler{next: next, counters: counters, levels: counters.forPrefix("")}
+}
+
+func (h *metricsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *metricsHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.levels[LevelOf(r.Level)-LevelTrace].Add(1)
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *metricsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == PrefixKey {
+				c.levels = h.counters.forPrefix(a.Value.String())
+			}
+		}
+	}
+
+	return &c
+}
+
+func (h *metricsHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	c.grouped = c.grouped || name != ""
+
+	return &c
+}
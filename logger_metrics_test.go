@@ -0,0 +1,81 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// gateHandler blocks the writer in Handle until release is closed, signalling
+// on started when it first does, and records the messages it handled.
+type gateHandler struct {
+	started chan struct{}
+	release chan struct{}
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (h *gateHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *gateHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	select {
+	case <-h.started:
+	default:
+		close(h.started)
+	}
+	h.mu.Unlock()
+
+	<-h.release
+
+	h.mu.Lock()
+	h.msgs = append(h.msgs, r.Message)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *gateHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return slices.Clone(h.msgs)
+}
+
+func (h *gateHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *gateHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestMetricsSkipAsyncDrops(t *testing.T) {
+	gate := newGateHandler()
+	al := NewAppLogger(&Config{
+		Handler: gate,
+		Async:   &AsyncConfig{BufferSize: 4, Overflow: OverflowDropNewest},
+	}).(*appLogger)
+	logger := al.GetLogger("db")
+
+	// The writer holds the first record while the queue fills up.
+	logger.Info("first")
+	<-gate.started
+	for range 10 {
+		logger.Info("queued or dropped")
+	}
+
+	close(gate.release)
+	if err := al.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := al.GetLogMetrics()
+	if m.DroppedAsync != 6 {
+		t.Errorf("DroppedAsync = %d, want 6", m.DroppedAsync)
+	}
+	if got := m.Count("db", LevelInfo); got != 5 {
+		t.Errorf("info records written = %d, want 5", got)
+	}
+}
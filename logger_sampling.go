@@ -0,0 +1,307 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSamplingInterval    = time.Second
+	defaultDropSummaryInterval = time.Minute
+	maxSampleKeys              = 10000
+	maxSummaryKeys             = 10
+)
+
+// SamplingConfig controls how repeated records are thinned out. Within every
+// Interval the first Initial records with the same level and message are
+// written, after that only every Thereafter-th one. Fatal and panic records
+// are never sampled.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+	Interval   time.Duration
+}
+
+// RateLimitConfig caps the number of records written per second across all
+// messages and levels. Fatal and panic records are never rate limited.
+type RateLimitConfig struct {
+	PerSecond int
+	Burst     int
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+type sampleCounter struct {
+	windowEnd time.Time
+	n         int
+}
+
+type sampler struct {
+	sampling *SamplingConfig
+	rate     *RateLimitConfig
+	out      slog.Handler
+
+	mu       sync.Mutex
+	counters map[sampleKey]*sampleCounter
+	dropped  map[sampleKey]uint64
+	limited  uint64
+	tokens   float64
+	refilled time.Time
+
+	// Totals since creation, unlike dropped and limited which are reset by
+	// every summary.
+	totalDropped atomic.Uint64
+	totalLimited atomic.Uint64
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+func newSampler(sampling *SamplingConfig, rate *RateLimitConfig, out slog.Handler) *sampler {
+	if sampling != nil {
+		s := *sampling
+		if s.Interval <= 0 {
+			s.Interval = defaultSamplingInterval
+		}
+		sampling = &s
+	}
+
+	if rate != nil {
+		r := *rate
+		if r.Burst <= 0 {
+			r.Burst = r.PerSecond
+		}
+		rate = &r
+	}
+
+	s := &sampler{
+		sampling: sampling,
+		rate:     rate,
+		out:      out,
+		counters: make(map[sampleKey]*sampleCounter),
+		dropped:  make(map[sampleKey]uint64),
+		refilled: time.Now(),
+		done:     make(chan struct{}),
+	}
+
+	if rate != nil {
+		s.tokens = float64(rate.Burst)
+	}
+
+	return s
+}
+
+// allow reports whether a record with the given level and message should be
+// written, counting it as dropped otherwise.
+func (s *sampler) allow(level slog.Level, msg string) bool {
+	if level >= LevelFatal.Level() {
+		return true
+	}
+
+	now := time.Now()
+	key := sampleKey{level: level, msg: msg}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sampling != nil && !s.sample(key, now) {
+		s.dropped[key]++
+		s.totalDropped.Add(1)
+		return false
+	}
+
+	if s.rate != nil && !s.take(now) {
+		s.limited++
+		s.totalLimited.Add(1)
+		return false
+	}
+
+	return true
+}
+
+func (s *sampler) sample(key sampleKey, now time.Time) bool {
+	c, ok := s.counters[key]
+	if !ok {
+		if len(s.counters) >= maxSampleKeys {
+			s.counters = make(map[sampleKey]*sampleCounter)
+		}
+		c = &sampleCounter{}
+		s.counters[key] = c
+	}
+
+	if now.After(c.windowEnd) {
+		c.windowEnd = now.Add(s.sampling.Interval)
+		c.n = 0
+	}
+
+	c.n++
+	if c.n <= s.sampling.Initial {
+		return true
+	}
+
+	if s.sampling.Thereafter <= 0 {
+		return false
+	}
+
+	return (c.n-s.sampling.Initial)%s.sampling.Thereafter == 0
+}
+
+func (s *sampler) take(now time.Time) bool {
+	if s.rate.PerSecond <= 0 {
+		return true
+	}
+
+	s.tokens += now.Sub(s.refilled).Seconds() * float64(s.rate.PerSecond)
+	if s.tokens > float64(s.rate.Burst) {
+		s.tokens = float64(s.rate.Burst)
+	}
+	s.refilled = now
+
+	if s.tokens < 1 {
+		return false
+	}
+
+	s.tokens--
+
+	return true
+}
+
+func (s *sampler) start(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultDropSummaryInterval
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.summarize()
+			case <-s.done:
+				s.summarize()
+				return
+			}
+		}
+	}()
+}
+
+func (s *sampler) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+}
+
+// summarize writes one warning per message that had records dropped since the
+// previous summary, largest first, and resets the counters.
+func (s *sampler) summarize() {
+	s.mu.Lock()
+	dropped := s.dropped
+	limited := s.limited
+	s.dropped = make(map[sampleKey]uint64)
+	s.limited = 0
+
+	now := time.Now()
+	for k, c := range s.counters {
+		if now.After(c.windowEnd) {
+			delete(s.counters, k)
+		}
+	}
+	s.mu.Unlock()
+
+	keys := make([]sampleKey, 0, len(dropped))
+	for k := range dropped {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return dropped[keys[i]] > dropped[keys[j]] })
+
+	var omitted uint64
+	for i, k := range keys {
+		if i >= maxSummaryKeys {
+			omitted += dropped[k]
+			continue
+		}
+		s.emit("Log records dropped by sampling",
+			slog.String("reason", "sampling"),
+			slog.String("dropped_level", levelName(k.level)),
+			slog.String("dropped_msg", k.msg),
+			slog.Uint64("count", dropped[k]),
+		)
+	}
+
+	if omitted > 0 {
+		s.emit("Log records dropped by sampling",
+			slog.String("reason", "sampling"),
+			slog.Int("other_messages", len(keys)-maxSummaryKeys),
+			slog.Uint64("count", omitted),
+		)
+	}
+
+	if limited > 0 {
+		s.emit("Log records dropped by rate limit",
+			slog.String("reason", "rate_limit"),
+			slog.Uint64("count", limited),
+		)
+	}
+}
+
+func (s *sampler) emit(msg string, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if !s.out.Enabled(ctx, slog.LevelWarn) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, msg, 0)
+	r.AddAttrs(attrs...)
+	_ = s.out.Handle(ctx, r)
+}
+
+// samplingHandler drops records rejected by the shared sampler before they
+// reach the next handler.
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !isCaptureFlush(ctx) && !h.sampler.allow(r.Level, r.Message) {
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
+
+func levelName(level slog.Level) string {
+	for l := LevelTrace; l <= LevelPanic; l++ {
+		if l.Level() == level {
+			return l.String()
+		}
+	}
+
+	return level.String()
+}
@@ -0,0 +1,168 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordHandler keeps the records it handles.
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func attrsOf(r slog.Record) map[string]any {
+	attrs := make(map[string]any)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	return attrs
+}
+
+func TestSample(t *testing.T) {
+	s := newSampler(&SamplingConfig{Initial: 2, Thereafter: 3, Interval: time.Second}, nil, &recordHandler{})
+	t0 := time.Now()
+	key := sampleKey{level: slog.LevelInfo, msg: "tick"}
+
+	// The first two records, then every third.
+	want := []bool{true, true, false, false, true, false, false, true, false, false}
+	for i, w := range want {
+		if got := s.sample(key, t0); got != w {
+			t.Errorf("record %d: sample = %v, want %v", i+1, got, w)
+		}
+	}
+
+	// Other messages and levels are counted separately.
+	if !s.sample(sampleKey{level: slog.LevelInfo, msg: "tock"}, t0) {
+		t.Error("first record of another message was dropped")
+	}
+	if !s.sample(sampleKey{level: slog.LevelWarn, msg: "tick"}, t0) {
+		t.Error("first record of another level was dropped")
+	}
+
+	// A new interval starts over.
+	if !s.sample(key, t0.Add(2*time.Second)) {
+		t.Error("first record of a new interval was dropped")
+	}
+}
+
+func TestSampleWithoutThereafter(t *testing.T) {
+	s := newSampler(&SamplingConfig{Initial: 1}, nil, &recordHandler{})
+	t0 := time.Now()
+	key := sampleKey{level: slog.LevelInfo, msg: "tick"}
+
+	want := []bool{true, false, false, false}
+	for i, w := range want {
+		if got := s.sample(key, t0); got != w {
+			t.Errorf("record %d: sample = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	s := newSampler(nil, &RateLimitConfig{PerSecond: 2, Burst: 3}, &recordHandler{})
+	t0 := time.Now()
+	s.refilled = t0
+
+	steps := []struct {
+		at   time.Duration
+		want bool
+	}{
+		// The burst is available at once.
+		{0, true},
+		{0, true},
+		{0, true},
+		{0, false},
+		// Tokens refill at PerSecond.
+		{250 * time.Millisecond, false},
+		{500 * time.Millisecond, true},
+		{500 * time.Millisecond, false},
+		// The bucket holds at most Burst tokens.
+		{10 * time.Second, true},
+		{10 * time.Second, true},
+		{10 * time.Second, true},
+		{10 * time.Second, false},
+	}
+
+	for i, st := range steps {
+		if got := s.take(t0.Add(st.at)); got != st.want {
+			t.Errorf("step %d at %v: take = %v, want %v", i, st.at, got, st.want)
+		}
+	}
+}
+
+func TestSamplerNeverDropsFatal(t *testing.T) {
+	s := newSampler(&SamplingConfig{Initial: 1}, &RateLimitConfig{PerSecond: 1, Burst: 1}, &recordHandler{})
+
+	for range 3 {
+		if !s.allow(LevelFatal.Level(), "boom") || !s.allow(LevelPanic.Level(), "boom") {
+			t.Fatal("fatal or panic record dropped")
+		}
+	}
+}
+
+func TestSamplerSummary(t *testing.T) {
+	out := &recordHandler{}
+	s := newSampler(&SamplingConfig{Initial: 1}, &RateLimitConfig{PerSecond: 1, Burst: 2}, out)
+
+	for range 5 {
+		s.allow(slog.LevelInfo, "a")
+	}
+	for range 2 {
+		s.allow(slog.LevelWarn, "b")
+	}
+	// Sampling let through one "a" and one "b", the rate limit allows
+	// neither of these.
+	s.allow(slog.LevelInfo, "c")
+	s.allow(slog.LevelInfo, "d")
+
+	s.summarize()
+
+	if len(out.records) != 3 {
+		t.Fatalf("got %d summary records, want 3", len(out.records))
+	}
+
+	want := []map[string]any{
+		{"reason": "sampling", "dropped_level": "info", "dropped_msg": "a", "count": uint64(4)},
+		{"reason": "sampling", "dropped_level": "warn", "dropped_msg": "b", "count": uint64(1)},
+		{"reason": "rate_limit", "count": uint64(2)},
+	}
+	for i, r := range out.records {
+		if r.Level != slog.LevelWarn || r.PC != 0 {
+			t.Errorf("summary %d: level %v pc %d, want WARN without a caller", i, r.Level, r.PC)
+		}
+		got := attrsOf(r)
+		for k, v := range want[i] {
+			if got[k] != v {
+				t.Errorf("summary %d: %s = %v, want %v", i, k, got[k], v)
+			}
+		}
+	}
+
+	// The counters are reset by every summary.
+	s.summarize()
+	if len(out.records) != 3 {
+		t.Errorf("second summary wrote %d records, want none", len(out.records)-3)
+	}
+
+	if s.totalDropped.Load() != 5 || s.totalLimited.Load() != 2 {
+		t.Errorf("totals = %d dropped, %d limited, want 5 and 2", s.totalDropped.Load(), s.totalLimited.Load())
+	}
+}
@@ -0,0 +1,85 @@
+package sctx
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/phathdt/service-context/internal/errinfo"
+)
+
+// levelSet is a bit set of CustomLevels.
+type levelSet uint16
+
+const allLevels levelSet = 1<<(LevelPanic-LevelTrace+1) - 1
+
+func levelSetOf(l CustomLevel) levelSet {
+	return 1 << (l - LevelTrace)
+}
+
+func (s levelSet) has(l CustomLevel) bool {
+	return l >= LevelTrace && l <= LevelPanic && s&levelSetOf(l) != 0
+}
+
+// hasSlog is like has for a raw slog level, matching the closest CustomLevel
+// at or below it.
+func (s levelSet) hasSlog(level slog.Level) bool {
+	return s.has(LevelOf(level))
+}
+
+// SourceRecorder is implemented by the loggers of this package.
+// RecordsSource reports whether records logged at level carry their caller,
+// so callers can skip computing a WithCallerSkip that wouldn't be used.
+// Custom Logger implementations don't need to implement it.
+type SourceRecorder interface {
+	RecordsSource(level CustomLevel) bool
+}
+
+func (l *logger) RecordsSource(level CustomLevel) bool {
+	return l.sources.has(level)
+}
+
+// sourceHandler drops the caller from records logged directly through
+// slog when their level is not configured to carry a source.
+type sourceHandler struct {
+	next    slog.Handler
+	sources levelSet
+}
+
+func (h *sourceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *sourceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.PC != 0 && !h.sources.hasSlog(r.Level) {
+		r.PC = 0
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *sourceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sourceHandler{next: h.next.WithAttrs(attrs), sources: h.sources}
+}
+
+func (h *sourceHandler) WithGroup(name string) slog.Handler {
+	return &sourceHandler{next: h.next.WithGroup(name), sources: h.sources}
+}
+
+// sourceString formats a source as "dir/file.go:line".
+func sourceString(src *slog.Source) string {
+	return errinfo.TrimPath(src.File) + ":" + strconv.Itoa(src.Line)
+}
+
+// compactSource replaces the source object slog produces with a
+// "dir/file.go:line" string, dropping it for records without a caller.
+func compactSource(a slog.Attr) slog.Attr {
+	if src, ok := a.Value.Any().(*slog.Source); ok {
+		if src.File == "" {
+			return slog.Attr{}
+		}
+		a.Value = slog.StringValue(sourceString(src))
+	}
+
+	return a
+}
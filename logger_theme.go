@@ -0,0 +1,172 @@
+package sctx
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Color is an ANSI SGR escape sequence. The empty Color leaves text as is.
+type Color string
+
+const (
+	ColorReset         Color = "\033[0m"
+	ColorFaint         Color = "\033[2m"
+	ColorRed           Color = "\033[31m"
+	ColorGreen         Color = "\033[32m"
+	ColorYellow        Color = "\033[33m"
+	ColorBlue          Color = "\033[34m"
+	ColorMagenta       Color = "\033[35m"
+	ColorCyan          Color = "\033[36m"
+	ColorBrightRed     Color = "\033[91m"
+	ColorBrightGreen   Color = "\033[92m"
+	ColorBrightYellow  Color = "\033[93m"
+	ColorBrightBlue    Color = "\033[94m"
+	ColorBrightMagenta Color = "\033[95m"
+	ColorBrightCyan    Color = "\033[96m"
+	ColorBackgroundRed Color = "\033[41m"
+)
+
+// Paint wraps s in c and a reset.
+func (c Color) Paint(s string) string {
+	if c == "" {
+		return s
+	}
+
+	return string(c) + s + string(ColorReset)
+}
+
+// DefaultTheme is the name of the theme used when Config.Theme is empty.
+const DefaultTheme = "default"
+
+// Theme holds the colors of the text format. Components that color their own
+// output, such as the pgxc query logger, read it from loggers implementing
+// Themed.
+type Theme struct {
+	Levels map[CustomLevel]Color
+	Prefix Color
+	// Key and Value color the attributes of a record.
+	Key   Color
+	Value Color
+	// SQL colors statements by type: "select", "insert", "update", "delete",
+	// "merge", "copy" and "ddl".
+	SQL map[string]Color
+}
+
+// Level returns the color of lvl.
+func (t *Theme) Level(lvl CustomLevel) Color {
+	return t.Levels[lvl]
+}
+
+// SQLType returns the color of statements of the given type.
+func (t *Theme) SQLType(sqlType string) Color {
+	return t.SQL[sqlType]
+}
+
+var (
+	themesMu sync.RWMutex
+	themes   = map[string]*Theme{
+		DefaultTheme: {
+			Levels: map[CustomLevel]Color{
+				LevelTrace: ColorFaint,
+				LevelDebug: ColorBrightBlue,
+				LevelInfo:  ColorBrightGreen,
+				LevelWarn:  ColorBrightYellow,
+				LevelError: ColorBrightRed,
+				LevelFatal: ColorBrightMagenta,
+				LevelPanic: ColorBackgroundRed + ColorBrightCyan,
+			},
+			SQL: map[string]Color{
+				"select": ColorBrightBlue,
+				"insert": ColorBrightGreen,
+				"update": ColorBrightYellow,
+				"delete": ColorBrightRed,
+				"merge":  ColorBrightMagenta,
+				"copy":   ColorBrightGreen,
+				"ddl":    ColorBrightCyan,
+			},
+		},
+	}
+)
+
+// RegisterTheme makes a theme available to Config.Theme under name,
+// replacing any theme registered with the same name. The theme must not be
+// modified afterwards.
+func RegisterTheme(name string, theme *Theme) {
+	themesMu.Lock()
+	defer themesMu.Unlock()
+
+	themes[name] = theme
+}
+
+// Themed is implemented by the loggers of this package. GetTheme returns the
+// colors of the text output, or nil when output isn't colored. Custom Logger
+// implementations don't need to implement it.
+type Themed interface {
+	GetTheme() *Theme
+}
+
+// LookupTheme returns the theme registered under name.
+func LookupTheme(name string) (*Theme, bool) {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+
+	t, ok := themes[name]
+	return t, ok
+}
+
+// themeFor returns the theme of the text format, or nil when output is not
+// colored: a custom Handler, other formats, NO_COLOR set, or stderr not a
+// terminal and FORCE_COLOR unset. Unknown theme names fall back to the
+// default theme, which NewAppLogger warns about.
+func themeFor(cfg *Config) *Theme {
+	if cfg.Handler != nil || cfg.Format != "text" || !colorEnabled(os.Stderr) {
+		return nil
+	}
+
+	if t, ok := LookupTheme(cfg.Theme); ok {
+		return t
+	}
+
+	t, _ := LookupTheme(DefaultTheme)
+	return t
+}
+
+// colorEnabled follows https://no-color.org and the FORCE_COLOR convention,
+// falling back to whether f is a terminal.
+func colorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	switch strings.ToLower(os.Getenv("FORCE_COLOR")) {
+	case "":
+	case "0", "false":
+		return false
+	default:
+		return true
+	}
+
+	return isatty.IsTerminal(f.Fd())
+}
+
+// paintAttr colors a record attribute for the text format.
+func (t *Theme) paintAttr(a slog.Attr) slog.Attr {
+	if a.Key == PrefixKey && t.Prefix != "" {
+		return slog.String(t.Key.Paint(a.Key), t.Prefix.Paint(a.Value.String()))
+	}
+
+	if t.Value != "" {
+		switch a.Value.Kind() {
+		case slog.KindString, slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool, slog.KindDuration:
+			a.Value = slog.StringValue(t.Value.Paint(a.Value.String()))
+		}
+	}
+
+	a.Key = t.Key.Paint(a.Key)
+
+	return a
+}
@@ -0,0 +1,40 @@
+package sctx_test
+
+import (
+	"log/slog"
+	"testing"
+
+	sctx "github.com/phathdt/service-context"
+	"github.com/phathdt/service-context/logtest"
+)
+
+func theme(l sctx.Logger) *sctx.Theme {
+	if t, ok := l.(sctx.Themed); ok {
+		return t.GetTheme()
+	}
+	return nil
+}
+
+func TestThemeOnlyForColoredText(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+
+	text := sctx.NewAppLogger(&sctx.Config{Format: "text"})
+	if theme(text.GetLogger("db")) == nil {
+		t.Error("colored text output has no theme")
+	}
+
+	json := sctx.NewAppLogger(&sctx.Config{Format: "json"})
+	if theme(json.GetLogger("db")) != nil {
+		t.Error("json output has a theme")
+	}
+
+	custom := sctx.NewAppLogger(&sctx.Config{Format: "text", Handler: slog.DiscardHandler})
+	if theme(custom.GetLogger("db")) != nil {
+		t.Error("output to a custom handler has a theme")
+	}
+
+	if theme(logtest.New().GetLogger("db")) != nil {
+		t.Error("logtest logger has a theme")
+	}
+}
@@ -0,0 +1,236 @@
+// Package logtest provides an in-memory sctx.AppLogger for tests that need to
+// assert on what code logged instead of scraping stderr.
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sctx "github.com/phathdt/service-context"
+)
+
+// Entry is a single captured log record. Attributes from groups are flattened
+// with dotted keys, e.g. "req.id".
+type Entry struct {
+	Time    time.Time
+	Level   sctx.CustomLevel
+	Message string
+	Prefix  string
+	Attrs   map[string]any
+}
+
+// Logger is an sctx.AppLogger that records every entry in memory.
+type Logger struct {
+	sctx.AppLogger
+	rec *recorder
+}
+
+// New returns a capturing logger. Every level down to trace is recorded.
+func New() *Logger {
+	rec := &recorder{}
+
+	return &Logger{
+		AppLogger: sctx.NewAppLogger(&sctx.Config{
+			DefaultLevel: "trace",
+			Handler:      &handler{rec: rec},
+		}),
+		rec: rec,
+	}
+}
+
+// Install creates a capturing logger, sets it as the global logger and
+// restores the previous global logger when the test finishes.
+func Install(t testing.TB) *Logger {
+	t.Helper()
+
+	l := New()
+	prev := sctx.SwapGlobalLogger(l.AppLogger)
+	t.Cleanup(func() { sctx.SetGlobalLogger(prev) })
+
+	return l
+}
+
+// Entries returns a copy of the captured entries in logging order.
+func (l *Logger) Entries() []Entry {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+
+	return append([]Entry(nil), l.rec.entries...)
+}
+
+// Reset drops all captured entries.
+func (l *Logger) Reset() {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+
+	l.rec.entries = nil
+}
+
+// Filter returns the entries at the given level whose message contains
+// msgContains and whose attributes match the key-value pairs in attrs.
+func (l *Logger) Filter(level sctx.CustomLevel, msgContains string, attrs ...any) []Entry {
+	var found []Entry
+
+	for _, e := range l.Entries() {
+		if e.Level == level && strings.Contains(e.Message, msgContains) && e.matches(attrs) {
+			found = append(found, e)
+		}
+	}
+
+	return found
+}
+
+// HasEntry reports whether an entry matching level, msgContains and attrs was
+// captured. Attributes are given as alternating keys and values; the key
+// "prefix" matches the logger prefix.
+func (l *Logger) HasEntry(level sctx.CustomLevel, msgContains string, attrs ...any) bool {
+	return len(l.Filter(level, msgContains, attrs...)) > 0
+}
+
+// AssertEntry fails the test when no entry matches, listing what was captured.
+func (l *Logger) AssertEntry(t testing.TB, level sctx.CustomLevel, msgContains string, attrs ...any) {
+	t.Helper()
+
+	if l.HasEntry(level, msgContains, attrs...) {
+		return
+	}
+
+	var b strings.Builder
+	for _, e := range l.Entries() {
+		fmt.Fprintf(&b, "\n\t%s %q prefix=%q %v", e.Level, e.Message, e.Prefix, e.Attrs)
+	}
+
+	t.Errorf("no %s entry containing %q with attrs %v; captured:%s", level, msgContains, attrs, b.String())
+}
+
+// AssertNoEntry fails the test when an entry matches.
+func (l *Logger) AssertNoEntry(t testing.TB, level sctx.CustomLevel, msgContains string, attrs ...any) {
+	t.Helper()
+
+	if found := l.Filter(level, msgContains, attrs...); len(found) > 0 {
+		t.Errorf("unexpected %s entry containing %q with attrs %v: %+v", level, msgContains, attrs, found)
+	}
+}
+
+func (e Entry) matches(attrs []any) bool {
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key := fmt.Sprint(attrs[i])
+
+		var got any
+		if key == sctx.PrefixKey {
+			got = e.Prefix
+		} else {
+			v, ok := e.Attrs[key]
+			if !ok {
+				return false
+			}
+			got = v
+		}
+
+		if !equal(got, attrs[i+1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equal compares loosely so that int literals match the int64 values slog
+// stores.
+func equal(got, want any) bool {
+	return reflect.DeepEqual(got, want) || fmt.Sprint(got) == fmt.Sprint(want)
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+type handler struct {
+	rec    *recorder
+	attrs  []slog.Attr
+	groups string
+}
+
+func (h *handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	e := Entry{
+		Time:    r.Time,
+		Level:   sctx.LevelOf(r.Level),
+		Message: r.Message,
+		Attrs:   make(map[string]any),
+	}
+
+	for _, a := range h.attrs {
+		e.add("", a)
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		e.add(h.groups, a)
+		return true
+	})
+
+	h.rec.mu.Lock()
+	h.rec.entries = append(h.rec.entries, e)
+	h.rec.mu.Unlock()
+
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if h.groups != "" {
+			a.Key = h.groups + a.Key
+		}
+		c.attrs = append(c.attrs, a)
+	}
+
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	c := *h
+	c.groups = h.groups + name + "."
+
+	return &c
+}
+
+func (e *Entry) add(prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			e.add(prefix, ga)
+		}
+		return
+	}
+
+	if a.Key == "" {
+		return
+	}
+
+	if prefix == "" && a.Key == sctx.PrefixKey {
+		e.Prefix = a.Value.String()
+		return
+	}
+
+	e.Attrs[prefix+a.Key] = a.Value.Any()
+}
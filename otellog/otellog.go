@@ -0,0 +1,229 @@
+// Package otellog bridges records logged through sctx.Logger to an
+// OpenTelemetry LoggerProvider, e.g. one backed by an OTLP exporter.
+//
+//	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(
+//		sdklog.NewBatchProcessor(exporter),
+//	))
+//	sctx.SetGlobalLogger(sctx.NewAppLogger(&sctx.Config{
+//		Outputs: []slog.Handler{otellog.NewHandler(provider)},
+//	}))
+//
+// Records carry the context passed with Logger.WithContext, so the SDK
+// correlates them with the span active in that context.
+package otellog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	sctx "github.com/phathdt/service-context"
+	"go.opentelemetry.io/otel/log"
+)
+
+// ScopeName is the default instrumentation scope of emitted records.
+const ScopeName = "github.com/phathdt/service-context"
+
+type options struct {
+	scope   string
+	version string
+}
+
+// Option configures the handler returned by NewHandler.
+type Option func(*options)
+
+// WithScope overrides the instrumentation scope name and version.
+func WithScope(name, version string) Option {
+	return func(o *options) {
+		o.scope = name
+		o.version = version
+	}
+}
+
+// Severity maps sctx levels to OpenTelemetry severity numbers. Levels between
+// the sctx levels round down to the closest one.
+func Severity(level slog.Level) (log.Severity, string) {
+	switch {
+	case level >= sctx.LevelPanic.Level():
+		return log.SeverityFatal2, "PANIC"
+	case level >= sctx.LevelFatal.Level():
+		return log.SeverityFatal1, "FATAL"
+	case level >= sctx.LevelError.Level():
+		return log.SeverityError1, "ERROR"
+	case level >= sctx.LevelWarn.Level():
+		return log.SeverityWarn1, "WARN"
+	case level >= sctx.LevelInfo.Level():
+		return log.SeverityInfo1, "INFO"
+	case level >= sctx.LevelDebug.Level():
+		return log.SeverityDebug1, "DEBUG"
+	default:
+		return log.SeverityTrace1, "TRACE"
+	}
+}
+
+// group holds the attributes added under one WithGroup name.
+type group struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// Handler is a slog.Handler that emits records to an OpenTelemetry Logger.
+type Handler struct {
+	logger log.Logger
+	groups []group
+}
+
+// NewHandler returns a handler emitting to a logger from provider.
+func NewHandler(provider log.LoggerProvider, opts ...Option) *Handler {
+	o := options{scope: ScopeName}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var lopts []log.LoggerOption
+	if o.version != "" {
+		lopts = append(lopts, log.WithInstrumentationVersion(o.version))
+	}
+
+	return &Handler{
+		logger: provider.Logger(o.scope, lopts...),
+		groups: []group{{}},
+	}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	sev, _ := Severity(level)
+	return h.logger.Enabled(ctx, log.EnabledParameters{Severity: sev})
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var rec log.Record
+
+	sev, text := Severity(r.Level)
+	rec.SetTimestamp(r.Time)
+	rec.SetObservedTimestamp(time.Now())
+	rec.SetSeverity(sev)
+	rec.SetSeverityText(text)
+	rec.SetBody(log.StringValue(r.Message))
+
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		rec.AddAttributes(
+			log.String("code.function.name", f.Function),
+			log.String("code.file.path", f.File),
+			log.Int("code.line.number", f.Line),
+		)
+	}
+
+	last := len(h.groups) - 1
+	attrs := make([]slog.Attr, len(h.groups[last].attrs), len(h.groups[last].attrs)+r.NumAttrs())
+	copy(attrs, h.groups[last].attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	for i := last; i > 0; i-- {
+		g := h.groups[i]
+		if len(attrs) > 0 {
+			attrs = append(append([]slog.Attr(nil), h.groups[i-1].attrs...), slog.Attr{Key: g.name, Value: slog.GroupValue(attrs...)})
+		} else {
+			attrs = h.groups[i-1].attrs
+		}
+	}
+
+	rec.AddAttributes(convertAttrs(attrs)...)
+	h.logger.Emit(ctx, rec)
+
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	c := h.clone()
+	last := &c.groups[len(c.groups)-1]
+	last.attrs = append(append([]slog.Attr(nil), last.attrs...), attrs...)
+
+	return c
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	c := h.clone()
+	c.groups = append(c.groups, group{name: name})
+
+	return c
+}
+
+func (h *Handler) clone() *Handler {
+	return &Handler{logger: h.logger, groups: append([]group(nil), h.groups...)}
+}
+
+func convertAttrs(attrs []slog.Attr) []log.KeyValue {
+	kvs := make([]log.KeyValue, 0, len(attrs))
+
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+
+		if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+			kvs = append(kvs, convertAttrs(a.Value.Group())...)
+			continue
+		}
+
+		kvs = append(kvs, log.KeyValue{Key: a.Key, Value: convertValue(a.Value)})
+	}
+
+	return kvs
+}
+
+func convertValue(v slog.Value) log.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return log.StringValue(v.String())
+	case slog.KindInt64:
+		return log.Int64Value(v.Int64())
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= 1<<63-1 {
+			return log.Int64Value(int64(u))
+		}
+		return log.StringValue(v.String())
+	case slog.KindFloat64:
+		return log.Float64Value(v.Float64())
+	case slog.KindBool:
+		return log.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return log.Int64Value(v.Duration().Nanoseconds())
+	case slog.KindTime:
+		return log.StringValue(v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		return log.MapValue(convertAttrs(v.Group())...)
+	}
+
+	switch x := v.Any().(type) {
+	case []byte:
+		return log.BytesValue(x)
+	case []string:
+		vs := make([]log.Value, len(x))
+		for i, s := range x {
+			vs[i] = log.StringValue(s)
+		}
+		return log.SliceValue(vs...)
+	case error:
+		return log.StringValue(x.Error())
+	case fmt.Stringer:
+		return log.StringValue(x.String())
+	default:
+		return log.StringValue(fmt.Sprint(x))
+	}
+}
@@ -0,0 +1,153 @@
+package otellog_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	sctx "github.com/phathdt/service-context"
+	"github.com/phathdt/service-context/otellog"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// memoryExporter keeps the exported records in memory.
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) Records() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]sdklog.Record(nil), e.records...)
+}
+
+func newLogger(t *testing.T) (sctx.Logger, *memoryExporter) {
+	t.Helper()
+
+	exp := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	app := sctx.NewAppLogger(&sctx.Config{
+		DefaultLevel: "trace",
+		Handler:      otellog.NewHandler(provider),
+	})
+
+	return app.GetLogger("api"), exp
+}
+
+func attributes(r sdklog.Record) map[string]log.Value {
+	attrs := make(map[string]log.Value)
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	return attrs
+}
+
+func TestHandlerEmitsRecord(t *testing.T) {
+	logger, exp := newLogger(t)
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.WithContext(ctx).Warnw("Order created", "order_id", 42, "paid", true)
+
+	records := exp.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	r := records[0]
+
+	if r.Severity() != log.SeverityWarn1 || r.SeverityText() != "WARN" {
+		t.Errorf("severity = %v %q, want %v %q", r.Severity(), r.SeverityText(), log.SeverityWarn1, "WARN")
+	}
+	if got := r.Body().AsString(); got != "Order created" {
+		t.Errorf("body = %q, want %q", got, "Order created")
+	}
+	if r.TraceID() != traceID || r.SpanID() != spanID {
+		t.Errorf("trace context = %s/%s, want %s/%s", r.TraceID(), r.SpanID(), traceID, spanID)
+	}
+
+	attrs := attributes(r)
+	if got := attrs[sctx.PrefixKey].AsString(); got != "api" {
+		t.Errorf("prefix = %q, want %q", got, "api")
+	}
+	if got := attrs["order_id"].AsInt64(); got != 42 {
+		t.Errorf("order_id = %d, want 42", got)
+	}
+	if got := attrs["paid"].AsBool(); !got {
+		t.Errorf("paid = %v, want true", got)
+	}
+}
+
+func TestHandlerGroups(t *testing.T) {
+	logger, exp := newLogger(t)
+
+	logger.WithGroup("req").Infow("Handled", "status", 200)
+
+	records := exp.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	req, ok := attributes(records[0])["req"]
+	if !ok || req.Kind() != log.KindMap {
+		t.Fatalf("req = %v, want a map", req)
+	}
+
+	kvs := req.AsMap()
+	if len(kvs) != 1 || kvs[0].Key != "status" || kvs[0].Value.AsInt64() != 200 {
+		t.Errorf("req = %v, want {status: 200}", kvs)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		level sctx.CustomLevel
+		want  log.Severity
+		text  string
+	}{
+		{sctx.LevelTrace, log.SeverityTrace1, "TRACE"},
+		{sctx.LevelDebug, log.SeverityDebug1, "DEBUG"},
+		{sctx.LevelInfo, log.SeverityInfo1, "INFO"},
+		{sctx.LevelWarn, log.SeverityWarn1, "WARN"},
+		{sctx.LevelError, log.SeverityError1, "ERROR"},
+		{sctx.LevelFatal, log.SeverityFatal1, "FATAL"},
+		{sctx.LevelPanic, log.SeverityFatal2, "PANIC"},
+	}
+
+	for _, tt := range tests {
+		sev, text := otellog.Severity(tt.level.Level())
+		if sev != tt.want || text != tt.text {
+			t.Errorf("Severity(%s) = %v %q, want %v %q", tt.level, sev, text, tt.want, tt.text)
+		}
+	}
+
+	// Levels between the defined ones round down.
+	if sev, _ := otellog.Severity(sctx.LevelInfo.Level() + 1); sev != log.SeverityInfo1 {
+		t.Errorf("Severity(INFO+1) = %v, want %v", sev, log.SeverityInfo1)
+	}
+}
@@ -0,0 +1,86 @@
+// Package promlog exposes the record counters of an sctx logger as
+// Prometheus metrics, so error rate spikes per subsystem can be alerted on
+// without shipping the logs anywhere.
+//
+//	prometheus.MustRegister(promlog.NewCollector(sctx.GlobalLogger().(sctx.LogMetricsProvider)))
+package promlog
+
+import (
+	sctx "github.com/phathdt/service-context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type options struct {
+	namespace   string
+	constLabels prometheus.Labels
+}
+
+// Option configures the collector returned by NewCollector.
+type Option func(*options)
+
+// WithNamespace prefixes the metric names with namespace.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// Collector is a prometheus.Collector reading LogMetrics on every scrape.
+type Collector struct {
+	provider sctx.LogMetricsProvider
+	records  *prometheus.Desc
+	dropped  *prometheus.Desc
+}
+
+// NewCollector returns a collector exporting
+//
+//	log_records_total{prefix, level}
+//	log_records_dropped_total{reason}
+//
+// where reason is "sampling", "rate_limit" or "async_overflow".
+func NewCollector(provider sctx.LogMetricsProvider, opts ...Option) *Collector {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Collector{
+		provider: provider,
+		records: prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, "log", "records_total"),
+			"Number of log records written, by prefix and level.",
+			[]string{"prefix", "level"}, o.constLabels,
+		),
+		dropped: prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, "log", "records_dropped_total"),
+			"Number of log records dropped before being written, by reason.",
+			[]string{"reason"}, o.constLabels,
+		),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.records
+	ch <- c.dropped
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.provider.GetLogMetrics()
+
+	for prefix, levels := range m.Records {
+		for level, n := range levels {
+			ch <- prometheus.MustNewConstMetric(c.records, prometheus.CounterValue, float64(n), prefix, level.String())
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(m.DroppedSampling), "sampling")
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(m.DroppedRateLimit), "rate_limit")
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(m.DroppedAsync), "async_overflow")
+}
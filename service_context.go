@@ -1,6 +1,7 @@
 package sctx
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -30,6 +31,8 @@ type serviceCtx struct {
 	components []Component
 	store      map[string]Component
 	logger     Logger
+	// unregisterExit removes the exit hook added by WithStopOnFatal.
+	unregisterExit func()
 }
 
 func NewServiceContext(opts ...Option) ServiceContext {
@@ -38,13 +41,13 @@ func NewServiceContext(opts ...Option) ServiceContext {
 		env:   DevEnv, // Default environment
 	}
 
-	sv.components = []Component{defaultLogger}
+	sv.components = []Component{globalAppLogger{}}
 
 	for _, opt := range opts {
 		opt(sv)
 	}
 
-	sv.logger = defaultLogger.GetLogger(sv.name)
+	sv.logger = GlobalLogger().GetLogger(sv.name)
 
 	return sv
 }
@@ -82,10 +85,15 @@ func (s *serviceCtx) Load() error {
 }
 
 func (s *serviceCtx) Logger(prefix string) Logger {
-	return defaultLogger.GetLogger(prefix)
+	return GlobalLogger().GetLogger(prefix)
 }
 
 func (s *serviceCtx) Stop() error {
+	if s.unregisterExit != nil {
+		s.unregisterExit()
+		s.unregisterExit = nil
+	}
+
 	s.logger.Info("Stopping service context")
 	for i := range s.components {
 		if err := s.components[i].Stop(); err != nil {
@@ -112,3 +120,25 @@ func WithComponent(c Component) Option {
 		}
 	}
 }
+
+// WithStopOnFatal stops the service context from an exit hook when a logger
+// calls Fatal, so components get to close connections before the process
+// exits. The wait is bounded by SetExitTimeout. The hook is removed when the
+// service context is stopped.
+func WithStopOnFatal() Option {
+	return func(s *serviceCtx) {
+		s.unregisterExit = RegisterExitHook(func(ctx context.Context) {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = s.Stop()
+			}()
+
+			select {
+			case <-done:
+			case <-ctx.Done():
+				s.logger.Warn("Timed out stopping service context before exit")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package sctx_test
+
+import (
+	"testing"
+
+	sctx "github.com/phathdt/service-context"
+	"github.com/phathdt/service-context/logtest"
+)
+
+type stubComponent struct {
+	id    string
+	stops int
+}
+
+func (c *stubComponent) ID() string                         { return c.id }
+func (c *stubComponent) Activate(sctx.ServiceContext) error { return nil }
+func (c *stubComponent) Stop() error                        { c.stops++; return nil }
+
+// exitCodes replaces the exit function for the test and records its calls.
+func exitCodes(t *testing.T) *[]int {
+	t.Helper()
+
+	var codes []int
+	t.Cleanup(sctx.SetExitFunc(func(code int) { codes = append(codes, code) }))
+
+	return &codes
+}
+
+func TestStopOnFatal(t *testing.T) {
+	logs := logtest.Install(t)
+	codes := exitCodes(t)
+
+	db := &stubComponent{id: "db"}
+	sc := sctx.NewServiceContext(sctx.WithName("svc"), sctx.WithComponent(db), sctx.WithStopOnFatal())
+	if err := sc.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	sctx.GlobalLogger().GetLogger("main").Fatalw("Cannot serve", "port", 4000)
+
+	if db.stops != 1 {
+		t.Errorf("component stopped %d times, want 1", db.stops)
+	}
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Errorf("exit codes = %v, want [1]", *codes)
+	}
+
+	logs.AssertEntry(t, sctx.LevelFatal, "Cannot serve", "port", 4000)
+	logs.AssertEntry(t, sctx.LevelInfo, "Service context stopped")
+}
+
+func TestStopRemovesExitHook(t *testing.T) {
+	logtest.Install(t)
+	codes := exitCodes(t)
+
+	db := &stubComponent{id: "db"}
+	sc := sctx.NewServiceContext(sctx.WithName("svc"), sctx.WithComponent(db), sctx.WithStopOnFatal())
+	if err := sc.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	sctx.GlobalLogger().GetLogger("main").Fatal("Cannot serve")
+
+	if db.stops != 1 {
+		t.Errorf("component stopped %d times, want 1", db.stops)
+	}
+	if len(*codes) != 1 {
+		t.Errorf("exit codes = %v, want one exit", *codes)
+	}
+}